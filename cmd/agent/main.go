@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"net"
 	"os"
 	"os/signal"
 	"syscall"
@@ -13,6 +14,8 @@ import (
 	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/client"
 	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/factory/storage/memory"
 	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/monitoring"
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/spool"
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/statsd"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"golang.org/x/sync/errgroup"
@@ -55,7 +58,33 @@ func main() {
 
 	storage := memory.NewMemoryStorage()
 	sender := client.NewClient(baseURI, 5*time.Second, cfg.Key, cfg.CryptoKey)
-	monitor := monitoring.NewMonitor(storage, sender)
+	sender.SetLabels(cfg.Labels)
+	retryPolicy := client.DefaultRetryPolicy()
+	retryPolicy.MaxAttempts = cfg.RetryAttempts
+	retryPolicy.BaseDelay = cfg.RetryBaseDelay.Duration
+	retryPolicy.MaxDelay = cfg.RetryMaxDelay.Duration
+	if cfg.RetryJitter != nil {
+		retryPolicy.Jitter = *cfg.RetryJitter
+	}
+	if len(cfg.RetryableCodes) > 0 {
+		retryPolicy.RetryableCodes = cfg.RetryableCodes
+	}
+	sender.SetRetryPolicy(retryPolicy)
+
+	registry, err := agent.NewCollectorRegistry(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Creating collectors")
+	}
+
+	monitor := monitoring.NewMonitor(storage, sender, registry)
+	if cfg.SpoolDir != "" {
+		sendSpool, err := spool.New(cfg.SpoolDir, cfg.SpoolMaxSize)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Opening the spool directory")
+		}
+		log.Info().Int("batches", sendSpool.Len()).Str("dir", cfg.SpoolDir).Msg("Spool is opened")
+		monitor.SetSpool(sendSpool)
+	}
 
 	metricsAgent := agent.NewMetricAgent(monitor)
 
@@ -68,6 +97,27 @@ func main() {
 		metricsAgent.RunReporting(gCtx, cfg.ReportInterval.Duration)
 		return nil
 	})
+	if cfg.StatsDAddress != "" {
+		conn, err := net.ListenPacket("udp", cfg.StatsDAddress)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Listening for StatsD metrics")
+		}
+		log.Info().Str("address", conn.LocalAddr().String()).Msg("StatsD listener is started")
+		statsdHandler := statsd.NewHandler(storage, cfg.StatsDBuckets)
+		g.Go(func() error {
+			return statsdHandler.ServeUDP(gCtx, conn)
+		})
+	}
+	if cfg.PushAddress != "" {
+		pushServer := agent.NewPushServer(cfg.PushAddress, storage)
+		g.Go(func() error {
+			return pushServer.Run()
+		})
+		g.Go(func() error {
+			<-gCtx.Done()
+			return pushServer.Shutdown(context.Background())
+		})
+	}
 	g.Go(func() error {
 		<-gCtx.Done()
 
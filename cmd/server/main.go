@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"net"
 	_ "net/http/pprof"
 	"os"
 	"os/signal"
@@ -13,9 +14,14 @@ import (
 	"golang.org/x/sync/errgroup"
 
 	"github.com/PostScripton/go-metrics-and-alerting-collection/config"
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/alerting"
 	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/factory"
 	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/factory/storage"
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/graphite"
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/metrics"
 	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/server"
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/statsd"
+	"github.com/PostScripton/go-metrics-and-alerting-collection/pkg/hashing/hmac"
 )
 
 const notAssigned = "N/A"
@@ -51,7 +57,10 @@ func main() {
 
 	cfg := config.NewServerConfig()
 
-	mainStorageFactory := &factory.StorageFactory{DSN: cfg.DatabaseDSN}
+	mainStorageFactory := &factory.StorageFactory{
+		DSN:              cfg.DatabaseDSN,
+		HistoryRetention: cfg.HistoryRetention.Duration,
+	}
 	mainStorage := mainStorageFactory.CreateStorage()
 	pingCtx, cancelPing := context.WithTimeout(context.Background(), 1*time.Second)
 	if err := mainStorage.Ping(pingCtx); err != nil {
@@ -69,7 +78,35 @@ func main() {
 	restorer := storage.NewRestorer(backupStorage, mainStorage)
 	restorer.Run(cfg.Restore, cfg.StoreInterval.Duration)
 
+	updateTracker := alerting.NewTracker()
 	coreServer := server.NewServer(cfg.Address, mainStorage, cfg.Key, cfg.CryptoKey)
+	coreServer.SetUpdateTracker(updateTracker)
+
+	var alertRules []alerting.Rule
+	if cfg.AlertRules != "" {
+		rules, err := alerting.LoadRules(cfg.AlertRules)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Loading alert rules")
+		}
+		alertRules = rules
+	}
+	var notifiers []alerting.Notifier
+	if len(cfg.AlertWebhooks) > 0 {
+		notifiers = append(notifiers, alerting.NewWebhookNotifier(cfg.AlertWebhooks, hmac.NewHmacSigner(), cfg.Key))
+	}
+	alertingEngine := alerting.NewEngine(mainStorage, updateTracker, alertRules, notifiers...)
+
+	statsdHandler := statsd.NewHandler(mainStorage, cfg.StatsDBuckets)
+	statsdHandler.SetUpdateTracker(updateTracker)
+	switch cfg.StatsDTimings {
+	case metrics.StringHistogramType:
+	case metrics.StringSummaryType:
+		if err := statsdHandler.SetSummary(cfg.StatsDAccuracy); err != nil {
+			log.Fatal().Err(err).Msg("Setting up summaries for StatsD timings")
+		}
+	default:
+		log.Fatal().Str("timings", cfg.StatsDTimings).Msg("Unsupported metric type for StatsD timings")
+	}
 
 	g, gCtx := errgroup.WithContext(ctx)
 	g.Go(func() error {
@@ -79,6 +116,44 @@ func main() {
 		<-gCtx.Done()
 		return coreServer.Shutdown(context.Background())
 	})
+	if cfg.StatsDUDPAddress != "" {
+		conn, err := net.ListenPacket("udp", cfg.StatsDUDPAddress)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Listening for StatsD metrics over UDP")
+		}
+		log.Info().Str("address", conn.LocalAddr().String()).Msg("StatsD UDP listener is started")
+		g.Go(func() error {
+			return statsdHandler.ServeUDP(gCtx, conn)
+		})
+	}
+	if cfg.StatsDTCPAddress != "" {
+		listener, err := net.Listen("tcp", cfg.StatsDTCPAddress)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Listening for StatsD metrics over TCP")
+		}
+		log.Info().Str("address", listener.Addr().String()).Msg("StatsD TCP listener is started")
+		g.Go(func() error {
+			return statsdHandler.ServeTCP(gCtx, listener)
+		})
+	}
+	if cfg.GraphiteAddress != "" {
+		listener, err := net.Listen("tcp", cfg.GraphiteAddress)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Listening for Graphite metrics")
+		}
+		log.Info().Str("address", listener.Addr().String()).Msg("Graphite listener is started")
+		graphiteHandler := graphite.NewHandler(mainStorage)
+		graphiteHandler.SetUpdateTracker(updateTracker)
+		g.Go(func() error {
+			return graphiteHandler.ServeTCP(gCtx, listener)
+		})
+	}
+	if len(alertRules) > 0 {
+		g.Go(func() error {
+			alertingEngine.Run(gCtx, cfg.AlertInterval.Duration)
+			return nil
+		})
+	}
 	g.Go(func() error {
 		<-gCtx.Done()
 		return restorer.Store()
@@ -3,7 +3,10 @@ package config
 import (
 	"encoding/json"
 	"flag"
+	"fmt"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/types"
@@ -15,10 +18,57 @@ type AgentConfig struct {
 	CommonConfig
 	ReportInterval types.Duration `env:"REPORT_INTERVAL" json:"report_interval"`
 	PollInterval   types.Duration `env:"POLL_INTERVAL" json:"poll_interval"`
+	Labels         types.Labels   `env:"LABELS" json:"labels"`
+	SpoolDir       string         `env:"SPOOL_DIR" json:"spool_dir"`
+	SpoolMaxSize   int64          `env:"SPOOL_MAX_SIZE" json:"spool_max_size"`
+	RetryAttempts  int            `env:"RETRY_ATTEMPTS" json:"retry_attempts"`
+	RetryBaseDelay types.Duration `env:"RETRY_BASE_DELAY" json:"retry_base_delay"`
+	RetryMaxDelay  types.Duration `env:"RETRY_MAX_DELAY" json:"retry_max_delay"`
+	RetryJitter    *bool          `env:"RETRY_JITTER" json:"retry_jitter"`
+	RetryableCodes []int          `env:"RETRYABLE_CODES" envSeparator:"," json:"retryable_codes"`
+	StatsDAddress  string         `env:"STATSD_ADDRESS" json:"statsd_address"`
+	StatsDBuckets  []float64      `env:"STATSD_BUCKETS" envSeparator:"," json:"statsd_buckets"`
+	PushAddress    string         `env:"PUSH_ADDRESS" json:"push_address"`
+
+	Collectors         map[string]CollectorConfig `json:"collectors"`
+	EnabledCollectors  []string                   `env:"ENABLED_COLLECTORS" envSeparator:"," json:"enabled_collectors"`
+	DisabledCollectors []string                   `env:"DISABLED_COLLECTORS" envSeparator:"," json:"disabled_collectors"`
+	Disk               DiskConfig                 `json:"disk"`
+	Net                NetConfig                  `json:"net"`
+	Processes          []ProcessConfig            `json:"processes"`
+	CgroupPath         string                     `env:"CGROUP_PATH" json:"cgroup_path"`
+	Exec               []ExecConfig               `json:"exec"`
+	Scrape             []ScrapeConfig             `json:"scrape"`
+	Logs               []LogConfig                `json:"logs"`
+}
+
+// DiskConfig фильтры источника метрик дисков. Шаблоны задаются в формате path.Match,
+// исключения имеют приоритет, пустой список включений пропускает всё
+type DiskConfig struct {
+	IncludeMountPoints []string `env:"DISK_INCLUDE_MOUNT_POINTS" envSeparator:"," json:"include_mount_points"`
+	ExcludeMountPoints []string `env:"DISK_EXCLUDE_MOUNT_POINTS" envSeparator:"," json:"exclude_mount_points"`
+	IncludeFSTypes     []string `env:"DISK_INCLUDE_FS_TYPES" envSeparator:"," json:"include_fs_types"`
+	ExcludeFSTypes     []string `env:"DISK_EXCLUDE_FS_TYPES" envSeparator:"," json:"exclude_fs_types"`
+	IncludeDevices     []string `env:"DISK_INCLUDE_DEVICES" envSeparator:"," json:"include_devices"`
+	ExcludeDevices     []string `env:"DISK_EXCLUDE_DEVICES" envSeparator:"," json:"exclude_devices"`
+}
+
+// CollectorConfig настройки источника метрик агента
+type CollectorConfig struct {
+	Enabled  *bool          `json:"enabled"`  // Включён ли источник, по умолчанию зависит от источника
+	Interval types.Duration `json:"interval"` // Интервал сбора, по умолчанию интервал опроса агента
 }
 
 const defaultReportInterval = 10 * time.Second
 const defaultPollInterval = 2 * time.Second
+const defaultSpoolDir = ""
+const defaultSpoolMaxSize = 64 << 20
+const defaultRetryAttempts = 3
+const defaultRetryBaseDelay = 100 * time.Millisecond
+const defaultRetryMaxDelay = 2 * time.Second
+const defaultCgroupPath = ""
+const defaultStatsDAddress = ""
+const defaultPushAddress = ""
 
 func NewAgentConfig() *AgentConfig {
 	var jsonCfg AgentConfig
@@ -30,6 +80,30 @@ func NewAgentConfig() *AgentConfig {
 	flag.DurationVar(&flagCfg.PollInterval.Duration, "p", defaultPollInterval, "An interval for polling metrics data")
 	flag.StringVar(&flagCfg.Key, "k", defaultKey, "A key for encrypting data")
 	flag.StringVar(&flagCfg.CryptoKey, "crypto-key", defaultCryptoKey, "A public key file")
+	flag.StringVar(&flagCfg.SpoolDir, "spool-dir", defaultSpoolDir, "A directory for batches that failed to be sent, empty disables spooling")
+	flag.Int64Var(&flagCfg.SpoolMaxSize, "spool-max-size", defaultSpoolMaxSize, "A size limit of the spool directory in bytes")
+	flag.IntVar(&flagCfg.RetryAttempts, "retry-attempts", defaultRetryAttempts, "Max attempts of sending a request, including the first one")
+	flag.DurationVar(&flagCfg.RetryBaseDelay.Duration, "retry-base-delay", defaultRetryBaseDelay, "A delay before the first retry, doubled on every next one")
+	flag.DurationVar(&flagCfg.RetryMaxDelay.Duration, "retry-max-delay", defaultRetryMaxDelay, "A max delay between retries")
+	flag.Func("retry-jitter", "Whether to add a random spread to retry delays (default true)", func(value string) error {
+		jitter, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		flagCfg.RetryJitter = &jitter
+		return nil
+	})
+	var retryableCodes string
+	flag.StringVar(&retryableCodes, "retryable-codes", "", "Comma-separated response status codes that are retried (default 429,502,503,504)")
+	flag.StringVar(&flagCfg.StatsDAddress, "statsd-address", defaultStatsDAddress, "A UDP address to receive StatsD metrics from local applications, e.g. localhost:8125, empty disables the listener")
+	flag.StringVar(&flagCfg.PushAddress, "push-address", defaultPushAddress, "An HTTP address to receive JSON metrics from local applications, e.g. localhost:8090, empty disables the push server")
+	flag.StringVar(&flagCfg.CgroupPath, "cgroup-path", defaultCgroupPath, "A cgroup v2 directory to read container metrics from, e.g. /sys/fs/cgroup, empty disables the collector")
+	var enabledCollectors string
+	flag.StringVar(&enabledCollectors, "enabled-collectors", "", "Comma-separated names of opt-in collectors that should run, e.g. cpu,disk,net,process")
+	var disabledCollectors string
+	flag.StringVar(&disabledCollectors, "disabled-collectors", "", "Comma-separated names of collectors that should not run")
+	var labels string
+	flag.StringVar(&labels, "labels", "", "Labels attached to every metric, e.g. host=web-1,env=prod")
 
 	var configFile struct {
 		Path string `env:"CONFIG"`
@@ -39,6 +113,27 @@ func NewAgentConfig() *AgentConfig {
 
 	flag.Parse()
 
+	if enabledCollectors != "" {
+		flagCfg.EnabledCollectors = strings.Split(enabledCollectors, ",")
+	}
+	if disabledCollectors != "" {
+		flagCfg.DisabledCollectors = strings.Split(disabledCollectors, ",")
+	}
+
+	if retryableCodes != "" {
+		codes, err := parseStatusCodes(retryableCodes)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Parsing retryable codes flag")
+			return nil
+		}
+		flagCfg.RetryableCodes = codes
+	}
+
+	if err := flagCfg.Labels.UnmarshalText([]byte(labels)); err != nil {
+		log.Fatal().Err(err).Msg("Parsing labels flag")
+		return nil
+	}
+
 	if err := env.Parse(&configFile); err != nil {
 		log.Fatal().Err(err).Msg("Parsing env to get config file")
 		return nil
@@ -84,6 +179,194 @@ func (c *AgentConfig) merge(other *AgentConfig) *AgentConfig {
 	if c.CryptoKey == "" {
 		c.CryptoKey = other.CryptoKey
 	}
+	if len(c.Labels) == 0 {
+		c.Labels = other.Labels
+	}
+	if c.SpoolDir == "" {
+		c.SpoolDir = other.SpoolDir
+	}
+	if c.SpoolMaxSize == 0 {
+		c.SpoolMaxSize = other.SpoolMaxSize
+	}
+	if c.RetryAttempts == 0 {
+		c.RetryAttempts = other.RetryAttempts
+	}
+	if c.RetryBaseDelay.Duration == 0 {
+		c.RetryBaseDelay = other.RetryBaseDelay
+	}
+	if c.RetryMaxDelay.Duration == 0 {
+		c.RetryMaxDelay = other.RetryMaxDelay
+	}
+	if c.RetryJitter == nil {
+		c.RetryJitter = other.RetryJitter
+	}
+	if len(c.RetryableCodes) == 0 {
+		c.RetryableCodes = other.RetryableCodes
+	}
+	if c.StatsDAddress == "" {
+		c.StatsDAddress = other.StatsDAddress
+	}
+	if len(c.StatsDBuckets) == 0 {
+		c.StatsDBuckets = other.StatsDBuckets
+	}
+	if c.PushAddress == "" {
+		c.PushAddress = other.PushAddress
+	}
+	if len(c.Collectors) == 0 {
+		c.Collectors = other.Collectors
+	}
+	if len(c.EnabledCollectors) == 0 {
+		c.EnabledCollectors = other.EnabledCollectors
+	}
+	if len(c.DisabledCollectors) == 0 {
+		c.DisabledCollectors = other.DisabledCollectors
+	}
+	c.Disk.merge(&other.Disk)
+	c.Net.merge(&other.Net)
+	if len(c.Processes) == 0 {
+		c.Processes = other.Processes
+	}
+	if c.CgroupPath == "" {
+		c.CgroupPath = other.CgroupPath
+	}
+	if len(c.Exec) == 0 {
+		c.Exec = other.Exec
+	}
+	if len(c.Scrape) == 0 {
+		c.Scrape = other.Scrape
+	}
+	if len(c.Logs) == 0 {
+		c.Logs = other.Logs
+	}
 
 	return c
 }
+
+// parseStatusCodes разбирает список кодов ответа, разделённых запятой
+func parseStatusCodes(value string) ([]int, error) {
+	parts := strings.Split(value, ",")
+	codes := make([]int, 0, len(parts))
+	for _, part := range parts {
+		code, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || code < 100 || code > 599 {
+			return nil, fmt.Errorf("invalid status code %q", part)
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+func (d *DiskConfig) merge(other *DiskConfig) *DiskConfig {
+	if len(d.IncludeMountPoints) == 0 {
+		d.IncludeMountPoints = other.IncludeMountPoints
+	}
+	if len(d.ExcludeMountPoints) == 0 {
+		d.ExcludeMountPoints = other.ExcludeMountPoints
+	}
+	if len(d.IncludeFSTypes) == 0 {
+		d.IncludeFSTypes = other.IncludeFSTypes
+	}
+	if len(d.ExcludeFSTypes) == 0 {
+		d.ExcludeFSTypes = other.ExcludeFSTypes
+	}
+	if len(d.IncludeDevices) == 0 {
+		d.IncludeDevices = other.IncludeDevices
+	}
+	if len(d.ExcludeDevices) == 0 {
+		d.ExcludeDevices = other.ExcludeDevices
+	}
+
+	return d
+}
+
+// NetConfig фильтры источника сетевых метрик по именам интерфейсов в формате path.Match
+type NetConfig struct {
+	IncludeInterfaces []string `env:"NET_INCLUDE_INTERFACES" envSeparator:"," json:"include_interfaces"`
+	ExcludeInterfaces []string `env:"NET_EXCLUDE_INTERFACES" envSeparator:"," json:"exclude_interfaces"`
+}
+
+func (n *NetConfig) merge(other *NetConfig) *NetConfig {
+	if len(n.IncludeInterfaces) == 0 {
+		n.IncludeInterfaces = other.IncludeInterfaces
+	}
+	if len(n.ExcludeInterfaces) == 0 {
+		n.ExcludeInterfaces = other.ExcludeInterfaces
+	}
+
+	return n
+}
+
+// ProcessConfig правило отбора процессов для источника метрик процессов.
+// Заданные условия должны выполняться одновременно
+type ProcessConfig struct {
+	Name    string `json:"name"`    // Значение метки process
+	Pattern string `json:"pattern"` // Регулярное выражение для имени процесса
+	Cmdline string `json:"cmdline"` // Регулярное выражение для командной строки
+	PidFile string `json:"pidfile"` // Файл с PID процесса
+	PerPid  bool   `json:"per_pid"` // Метрики каждого процесса с меткой pid вместо суммы по правилу
+}
+
+// ExecConfig пользовательская команда, печатающая метрики в stdout
+type ExecConfig struct {
+	Name     string            `json:"name"`     // Имя команды, источник называется exec:<name>
+	Command  []string          `json:"command"`  // Программа и её аргументы, запускается без оболочки
+	Format   string            `json:"format"`   // Формат вывода: line или json
+	Timeout  types.Duration    `json:"timeout"`  // Время на выполнение команды
+	Dir      string            `json:"dir"`      // Рабочая директория
+	Env      map[string]string `json:"env"`      // Дополнительные переменные окружения
+	Interval types.Duration    `json:"interval"` // Интервал запуска, по умолчанию интервал опроса агента
+}
+
+// ScrapeConfig HTTP-эндпоинт с метриками в текстовом формате Prometheus
+type ScrapeConfig struct {
+	Name     string            `json:"name"`     // Имя цели, источник называется scrape:<name>
+	URL      string            `json:"url"`      // Адрес эндпоинта
+	Timeout  types.Duration    `json:"timeout"`  // Время на запрос
+	Labels   map[string]string `json:"labels"`   // Метки, добавляемые ко всем метрикам цели
+	Interval types.Duration    `json:"interval"` // Интервал опроса, по умолчанию интервал опроса агента
+}
+
+// LogConfig лог-файл, строки которого считаются по правилам
+type LogConfig struct {
+	Name     string          `json:"name"`     // Имя источника, он называется log:<name>
+	Path     string          `json:"path"`     // Путь к лог-файлу
+	Rules    []LogRuleConfig `json:"rules"`    // Правила подсчёта строк
+	Interval types.Duration  `json:"interval"` // Интервал чтения, по умолчанию интервал опроса агента
+}
+
+// LogRuleConfig правило подсчёта строк лога
+type LogRuleConfig struct {
+	Counter     string    `json:"counter"`      // Название счётчика подходящих строк
+	Pattern     string    `json:"pattern"`      // Регулярное выражение для строки
+	Value       string    `json:"value"`        // Имя или номер группы с числовым значением
+	ValueMetric string    `json:"value_metric"` // Название метрики со значением группы
+	ValueType   string    `json:"value_type"`   // Тип метрики со значением: gauge или histogram
+	Buckets     []float64 `json:"buckets"`      // Границы корзин гистограммы
+}
+
+// CollectorEnabled проверяет, включён ли источник метрик с именем name. Отключение имеет приоритет над включением,
+// а если источник не упомянут в конфигурации, то используется enabledByDefault
+func (c *AgentConfig) CollectorEnabled(name string, enabledByDefault bool) bool {
+	for _, disabled := range c.DisabledCollectors {
+		if strings.TrimSpace(disabled) == name {
+			return false
+		}
+	}
+	for _, enabled := range c.EnabledCollectors {
+		if strings.TrimSpace(enabled) == name {
+			return true
+		}
+	}
+	if collectorCfg, ok := c.Collectors[name]; ok && collectorCfg.Enabled != nil {
+		return *collectorCfg.Enabled
+	}
+	return enabledByDefault
+}
+
+// CollectorInterval возвращает интервал сбора для источника метрик с именем name
+func (c *AgentConfig) CollectorInterval(name string) time.Duration {
+	if collectorCfg, ok := c.Collectors[name]; ok && collectorCfg.Interval.Duration > 0 {
+		return collectorCfg.Interval.Duration
+	}
+	return c.PollInterval.Duration
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"flag"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/types"
@@ -13,16 +14,34 @@ import (
 
 type ServerConfig struct {
 	CommonConfig
-	StoreInterval types.Duration `env:"STORE_INTERVAL" json:"store_interval"`
-	StoreFile     string         `env:"STORE_FILE" json:"store_file"`
-	Restore       bool           `env:"RESTORE" json:"restore"`
-	DatabaseDSN   string         `env:"DATABASE_DSN" json:"database_dsn"`
+	StoreInterval    types.Duration `env:"STORE_INTERVAL" json:"store_interval"`
+	StoreFile        string         `env:"STORE_FILE" json:"store_file"`
+	Restore          bool           `env:"RESTORE" json:"restore"`
+	DatabaseDSN      string         `env:"DATABASE_DSN" json:"database_dsn"`
+	AlertRules       string         `env:"ALERT_RULES" json:"alert_rules"`
+	AlertInterval    types.Duration `env:"ALERT_INTERVAL" json:"alert_interval"`
+	AlertWebhooks    []string       `env:"ALERT_WEBHOOKS" envSeparator:"," json:"alert_webhooks"`
+	HistoryRetention types.Duration `env:"HISTORY_RETENTION" json:"history_retention"`
+	StatsDUDPAddress string         `env:"STATSD_UDP_ADDRESS" json:"statsd_udp_address"`
+	StatsDTCPAddress string         `env:"STATSD_TCP_ADDRESS" json:"statsd_tcp_address"`
+	StatsDTimings    string         `env:"STATSD_TIMINGS" json:"statsd_timings"`
+	StatsDBuckets    []float64      `env:"STATSD_BUCKETS" envSeparator:"," json:"statsd_buckets"`
+	StatsDAccuracy   float64        `env:"STATSD_ACCURACY" json:"statsd_accuracy"`
+	GraphiteAddress  string         `env:"GRAPHITE_ADDRESS" json:"graphite_address"`
 }
 
 const defaultRestore = true
 const defaultStoreFile = "/tmp/devops-metrics-db.json"
 const defaultStoreInterval = 5 * time.Minute
 const defaultDatabaseDSN = ""
+const defaultAlertRules = ""
+const defaultAlertInterval = 10 * time.Second
+const defaultHistoryRetention = 0
+const defaultStatsDUDPAddress = ""
+const defaultStatsDTCPAddress = ""
+const defaultStatsDTimings = "histogram"
+const defaultStatsDAccuracy = 0.01
+const defaultGraphiteAddress = ""
 
 func NewServerConfig() *ServerConfig {
 	var jsonCfg ServerConfig
@@ -36,6 +55,16 @@ func NewServerConfig() *ServerConfig {
 	flag.StringVar(&flagCfg.Key, "k", defaultKey, "A key for encrypting data")
 	flag.StringVar(&flagCfg.DatabaseDSN, "d", defaultDatabaseDSN, "A DSN for connecting to database")
 	flag.StringVar(&flagCfg.CryptoKey, "crypto-key", defaultCryptoKey, "A private key file")
+	flag.StringVar(&flagCfg.AlertRules, "alert-rules", defaultAlertRules, "A path to the JSON file with alert rules")
+	flag.DurationVar(&flagCfg.AlertInterval.Duration, "alert-interval", defaultAlertInterval, "An interval for evaluating alert rules")
+	flag.DurationVar(&flagCfg.HistoryRetention.Duration, "history-retention", defaultHistoryRetention, "How long to keep the history of metric values, 0 keeps only the latest value")
+	flag.StringVar(&flagCfg.StatsDUDPAddress, "statsd-udp-address", defaultStatsDUDPAddress, "A UDP address to receive StatsD metrics on, e.g. :8125, empty disables the listener")
+	flag.StringVar(&flagCfg.StatsDTCPAddress, "statsd-tcp-address", defaultStatsDTCPAddress, "A TCP address to receive StatsD metrics on, e.g. :8125, empty disables the listener")
+	flag.StringVar(&flagCfg.StatsDTimings, "statsd-timings", defaultStatsDTimings, "A metric type for StatsD timings: histogram or summary")
+	flag.Float64Var(&flagCfg.StatsDAccuracy, "statsd-accuracy", defaultStatsDAccuracy, "A relative accuracy of summaries for StatsD timings")
+	flag.StringVar(&flagCfg.GraphiteAddress, "graphite-address", defaultGraphiteAddress, "A TCP address to receive Graphite plaintext metrics on, e.g. :2003, empty disables the listener")
+	var alertWebhooks string
+	flag.StringVar(&alertWebhooks, "alert-webhooks", "", "Comma-separated URLs to send alert notifications to")
 
 	var configFile struct {
 		Path string `env:"CONFIG"`
@@ -45,6 +74,10 @@ func NewServerConfig() *ServerConfig {
 
 	flag.Parse()
 
+	if alertWebhooks != "" {
+		flagCfg.AlertWebhooks = strings.Split(alertWebhooks, ",")
+	}
+
 	if err := env.Parse(&configFile); err != nil {
 		log.Fatal().Err(err).Msg("Parsing env to get config file")
 		return nil
@@ -96,6 +129,36 @@ func (c *ServerConfig) merge(other *ServerConfig) *ServerConfig {
 	if c.CryptoKey == "" {
 		c.CryptoKey = other.CryptoKey
 	}
+	if c.AlertRules == "" {
+		c.AlertRules = other.AlertRules
+	}
+	if c.AlertInterval.Duration == 0 {
+		c.AlertInterval = other.AlertInterval
+	}
+	if len(c.AlertWebhooks) == 0 {
+		c.AlertWebhooks = other.AlertWebhooks
+	}
+	if c.HistoryRetention.Duration == 0 {
+		c.HistoryRetention = other.HistoryRetention
+	}
+	if c.StatsDUDPAddress == "" {
+		c.StatsDUDPAddress = other.StatsDUDPAddress
+	}
+	if c.StatsDTCPAddress == "" {
+		c.StatsDTCPAddress = other.StatsDTCPAddress
+	}
+	if c.StatsDTimings == "" {
+		c.StatsDTimings = other.StatsDTimings
+	}
+	if len(c.StatsDBuckets) == 0 {
+		c.StatsDBuckets = other.StatsDBuckets
+	}
+	if c.StatsDAccuracy == 0 {
+		c.StatsDAccuracy = other.StatsDAccuracy
+	}
+	if c.GraphiteAddress == "" {
+		c.GraphiteAddress = other.GraphiteAddress
+	}
 
 	return c
 }
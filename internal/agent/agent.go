@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/monitoring"
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/monitoring/collector"
 )
 
 // Runner интерфейс агента управляет сбором и отправкой метрик
@@ -27,20 +28,34 @@ func NewMetricAgent(monitor monitoring.IMonitor) *MetricAgent {
 	}
 }
 
+// RunPolling запускает сбор метрик из каждого источника с его собственным интервалом.
+// Источники без своего интервала опрашиваются раз в interval
 func (a *MetricAgent) RunPolling(ctx context.Context, interval time.Duration) {
+	var wg sync.WaitGroup
+	for _, c := range a.monitor.Collectors() {
+		collectorInterval := c.Interval()
+		if collectorInterval <= 0 {
+			collectorInterval = interval
+		}
+
+		wg.Add(1)
+		go func(c collector.Collector, interval time.Duration) {
+			defer wg.Done()
+			a.runCollector(ctx, c, interval)
+		}(c, collectorInterval)
+	}
+	wg.Wait()
+}
+
+func (a *MetricAgent) runCollector(ctx context.Context, c collector.Collector, interval time.Duration) {
 	pollInterval := time.NewTicker(interval)
+	defer pollInterval.Stop()
 	for {
 		select {
 		case <-pollInterval.C:
-			a.wg.Add(2)
-			go func() {
-				a.monitor.GatherMain()
-				a.wg.Done()
-			}()
-			go func() {
-				a.monitor.GatherAdditional()
-				a.wg.Done()
-			}()
+			a.wg.Add(1)
+			a.monitor.Gather(ctx, c)
+			a.wg.Done()
 		case <-ctx.Done():
 			return
 		}
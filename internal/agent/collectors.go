@@ -0,0 +1,157 @@
+package agent
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/PostScripton/go-metrics-and-alerting-collection/config"
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/monitoring/collector"
+)
+
+// optInCollectors источники, которые собирают много метрик и запускаются, только если их явно включили
+var optInCollectors = map[string]bool{
+	collector.CPUName:     true,
+	collector.DiskName:    true,
+	collector.NetName:     true,
+	collector.ProcessName: true,
+}
+
+// NewCollectorRegistry создаёт и регистрирует источники метрик, включённые в конфигурации агента
+func NewCollectorRegistry(cfg *config.AgentConfig) (*collector.Registry, error) {
+	b := &registryBuilder{
+		cfg:      cfg,
+		registry: collector.NewRegistry(),
+	}
+
+	b.add(collector.RuntimeName, func(interval time.Duration) (collector.Collector, error) {
+		return collector.NewRuntimeCollector(interval), nil
+	})
+	b.add(collector.SystemName, func(interval time.Duration) (collector.Collector, error) {
+		return collector.NewSystemCollector(interval), nil
+	})
+	b.add(collector.CPUName, func(interval time.Duration) (collector.Collector, error) {
+		return collector.NewCPUCollector(interval), nil
+	})
+	b.add(collector.DiskName, func(interval time.Duration) (collector.Collector, error) {
+		return collector.NewDiskCollector(interval, collector.DiskFilters{
+			MountPoints: collector.Filter{Include: cfg.Disk.IncludeMountPoints, Exclude: cfg.Disk.ExcludeMountPoints},
+			FSTypes:     collector.Filter{Include: cfg.Disk.IncludeFSTypes, Exclude: cfg.Disk.ExcludeFSTypes},
+			Devices:     collector.Filter{Include: cfg.Disk.IncludeDevices, Exclude: cfg.Disk.ExcludeDevices},
+		})
+	})
+	b.add(collector.NetName, func(interval time.Duration) (collector.Collector, error) {
+		return collector.NewNetCollector(interval, collector.Filter{
+			Include: cfg.Net.IncludeInterfaces,
+			Exclude: cfg.Net.ExcludeInterfaces,
+		})
+	})
+	b.add(collector.ProcessName, func(interval time.Duration) (collector.Collector, error) {
+		rules := make([]collector.ProcessRule, 0, len(cfg.Processes))
+		for _, processCfg := range cfg.Processes {
+			rule, err := collector.NewProcessRule(processCfg.Name, processCfg.Pattern, processCfg.Cmdline, processCfg.PidFile)
+			if err != nil {
+				return nil, fmt.Errorf("process rule [%s]: %w", processCfg.Name, err)
+			}
+			rule.PerPid = processCfg.PerPid
+			rules = append(rules, rule)
+		}
+		return collector.NewProcessCollector(interval, rules)
+	})
+	if cfg.CgroupPath != "" {
+		b.add(collector.CgroupName, func(interval time.Duration) (collector.Collector, error) {
+			return collector.NewCgroupCollector(interval, cfg.CgroupPath), nil
+		})
+	}
+
+	for _, execCfg := range cfg.Exec {
+		execCfg := execCfg
+		b.addNamed(collector.ExecName, execCfg.Name, execCfg.Interval.Duration, func(interval time.Duration) (collector.Collector, error) {
+			env := make([]string, 0, len(execCfg.Env))
+			for name, value := range execCfg.Env {
+				env = append(env, name+"="+value)
+			}
+			return collector.NewExecCollector(collector.ExecCommand{
+				Name:     execCfg.Name,
+				Command:  execCfg.Command,
+				Format:   execCfg.Format,
+				Timeout:  execCfg.Timeout.Duration,
+				Dir:      execCfg.Dir,
+				Env:      env,
+				Interval: interval,
+			})
+		})
+	}
+	for _, scrapeCfg := range cfg.Scrape {
+		scrapeCfg := scrapeCfg
+		b.addNamed(collector.ScrapeName, scrapeCfg.Name, scrapeCfg.Interval.Duration, func(interval time.Duration) (collector.Collector, error) {
+			return collector.NewScrapeCollector(collector.ScrapeTarget{
+				Name:     scrapeCfg.Name,
+				URL:      scrapeCfg.URL,
+				Timeout:  scrapeCfg.Timeout.Duration,
+				Labels:   scrapeCfg.Labels,
+				Interval: interval,
+			})
+		})
+	}
+	for _, logCfg := range cfg.Logs {
+		logCfg := logCfg
+		b.addNamed(collector.LogTailName, logCfg.Name, logCfg.Interval.Duration, func(interval time.Duration) (collector.Collector, error) {
+			rules := make([]collector.LogRule, 0, len(logCfg.Rules))
+			for _, ruleCfg := range logCfg.Rules {
+				rule, err := collector.NewLogRule(ruleCfg.Counter, ruleCfg.Pattern, ruleCfg.Value, ruleCfg.ValueMetric, ruleCfg.ValueType, ruleCfg.Buckets)
+				if err != nil {
+					return nil, fmt.Errorf("log rule [%s]: %w", ruleCfg.Counter, err)
+				}
+				rules = append(rules, rule)
+			}
+			return collector.NewLogTailCollector(interval, logCfg.Name, logCfg.Path, rules)
+		})
+	}
+
+	if b.err != nil {
+		return nil, b.err
+	}
+	return b.registry, nil
+}
+
+// registryBuilder создаёт только включённые источники и запоминает первую ошибку
+type registryBuilder struct {
+	cfg      *config.AgentConfig
+	registry *collector.Registry
+	err      error
+}
+
+// add создаёт и регистрирует источник name с интервалом из конфигурации, если источник включён
+func (b *registryBuilder) add(name string, create func(interval time.Duration) (collector.Collector, error)) {
+	b.register(name, b.cfg.CollectorInterval(name), create)
+}
+
+// addNamed создаёт источник вида kind:name. Собственный интервал источника имеет приоритет
+func (b *registryBuilder) addNamed(kind string, name string, interval time.Duration, create func(interval time.Duration) (collector.Collector, error)) {
+	fullName := kind + ":" + name
+	if interval == 0 {
+		interval = b.cfg.CollectorInterval(fullName)
+	}
+	b.register(fullName, interval, create)
+}
+
+func (b *registryBuilder) register(name string, interval time.Duration, create func(interval time.Duration) (collector.Collector, error)) {
+	if b.err != nil {
+		return
+	}
+	if !b.cfg.CollectorEnabled(name, !optInCollectors[name]) {
+		log.Info().Str("collector", name).Msg("Collector is disabled")
+		return
+	}
+
+	c, err := create(interval)
+	if err != nil {
+		b.err = fmt.Errorf("creating collector %s: %w", name, err)
+		return
+	}
+	if err = b.registry.Register(c); err != nil {
+		b.err = err
+	}
+}
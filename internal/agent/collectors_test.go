@@ -0,0 +1,84 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PostScripton/go-metrics-and-alerting-collection/config"
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/monitoring/collector"
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/types"
+)
+
+func TestNewCollectorRegistry(t *testing.T) {
+	enabled := true
+	disabled := false
+	tests := []struct {
+		name    string
+		cfg     config.AgentConfig
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "Opt-in collectors are disabled by default",
+			want: []string{"runtime", "system"},
+		},
+		{
+			name: "Opt-in collectors are enabled by config or list",
+			cfg: config.AgentConfig{
+				Collectors: map[string]config.CollectorConfig{
+					collector.CPUName:     {Enabled: &enabled},
+					collector.SystemName:  {Enabled: &disabled},
+					collector.ProcessName: {Enabled: &enabled},
+				},
+				EnabledCollectors:  []string{"disk", " net"},
+				DisabledCollectors: []string{"process"},
+			},
+			want: []string{"cpu", "disk", "net", "runtime"},
+		},
+		{
+			name: "Configured sources",
+			cfg: config.AgentConfig{
+				CgroupPath: "/sys/fs/cgroup",
+				Exec:       []config.ExecConfig{{Name: "queue", Command: []string{"true"}}},
+				Scrape:     []config.ScrapeConfig{{Name: "node", URL: "http://localhost:9100/metrics"}},
+				Logs:       []config.LogConfig{{Name: "app", Path: "/var/log/app.log", Rules: []config.LogRuleConfig{{Counter: "Errors", Pattern: "ERROR"}}}},
+			},
+			want: []string{"cgroup", "exec:queue", "log:app", "runtime", "scrape:node", "system"},
+		},
+		{
+			name: "Invalid rules of a disabled collector are ignored",
+			cfg: config.AgentConfig{
+				Processes: []config.ProcessConfig{{Name: "broken", Pattern: "("}},
+			},
+			want: []string{"runtime", "system"},
+		},
+		{
+			name: "Invalid rules of an enabled collector",
+			cfg: config.AgentConfig{
+				EnabledCollectors: []string{"process"},
+				Processes:         []config.ProcessConfig{{Name: "broken", Pattern: "("}},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.PollInterval = types.Duration{Duration: time.Second}
+			registry, err := NewCollectorRegistry(&tt.cfg)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			names := make([]string, 0, len(tt.want))
+			for _, c := range registry.Collectors() {
+				names = append(names, c.Name())
+			}
+			assert.Equal(t, tt.want, names)
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/rs/zerolog/log"
+
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/factory/storage"
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/httpjson"
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/metrics"
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/server/middlewares"
+)
+
+// PushServer принимает метрики от локальных приложений в том же JSON-формате, что и сервер на /update и /updates,
+// и складывает их в буфер агента. Отправка на сервер, подпись и шифрование выполняются по обычному расписанию агента
+type PushServer struct {
+	core    *http.Server
+	storage storage.Storer
+}
+
+func NewPushServer(address string, storage storage.Storer) *PushServer {
+	s := &PushServer{
+		storage: storage,
+	}
+
+	router := chi.NewRouter()
+	router.Use(middleware.StripSlashes)
+	router.Use(middlewares.UnpackGzip)
+	router.Post("/update", s.UpdateMetricJSONHandler)
+	router.Post("/updates", s.UpdateMetricsBatchJSONHandler)
+
+	s.core = &http.Server{
+		Addr:    address,
+		Handler: router,
+	}
+
+	return s
+}
+
+// Run запускает приём метрик и блокируется до остановки сервера
+func (s *PushServer) Run() error {
+	log.Info().Str("address", s.core.Addr).Msg("The push server has just started")
+
+	if err := s.core.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (s *PushServer) Shutdown(ctx context.Context) error {
+	return s.core.Shutdown(ctx)
+}
+
+func (s *PushServer) UpdateMetricJSONHandler(rw http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Content-Type") != "application/json" {
+		httpjson.Write(rw, http.StatusBadRequest, httpjson.Obj{"message": "Invalid Content-Type"})
+		return
+	}
+
+	var metric metrics.Metrics
+	if err := httpjson.Parse(r, &metric); err != nil {
+		httpjson.Write(rw, http.StatusBadRequest, httpjson.Obj{"message": "Unable to parse JSON"})
+		return
+	}
+	if err := metric.ValidateWithValue(); err != nil {
+		httpjson.Write(rw, http.StatusBadRequest, httpjson.Obj{"message": err.Error()})
+		return
+	}
+
+	if err := s.store(metric); err != nil {
+		storeError(rw, err)
+		return
+	}
+
+	httpjson.Write(rw, http.StatusOK, httpjson.Obj{})
+}
+
+// UpdateMetricsBatchJSONHandler сохраняет пакет метрик, только если все метрики в нём корректны
+func (s *PushServer) UpdateMetricsBatchJSONHandler(rw http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Content-Type") != "application/json" {
+		httpjson.Write(rw, http.StatusBadRequest, httpjson.Obj{"message": "Invalid Content-Type"})
+		return
+	}
+
+	var collection []metrics.Metrics
+	if err := httpjson.Parse(r, &collection); err != nil {
+		httpjson.Write(rw, http.StatusBadRequest, httpjson.Obj{"message": "Unable to parse JSON"})
+		return
+	}
+	for i, metric := range collection {
+		if err := metric.ValidateWithValue(); err != nil {
+			httpjson.Write(rw, http.StatusBadRequest, httpjson.Obj{"message": fmt.Sprintf("Metric #%d: %s", i, err)})
+			return
+		}
+	}
+
+	for _, metric := range collection {
+		if err := s.store(metric); err != nil {
+			storeError(rw, err)
+			return
+		}
+	}
+
+	httpjson.Write(rw, http.StatusOK, httpjson.Obj{})
+}
+
+// storeError отвечает на запрос, метрики которого не удалось сохранить
+func storeError(rw http.ResponseWriter, err error) {
+	if metrics.IsIncompatible(err) {
+		httpjson.Write(rw, http.StatusBadRequest, httpjson.Obj{"message": fmt.Sprintf("Incompatible metric value: %s", err)})
+		return
+	}
+	httpjson.Write(rw, http.StatusInternalServerError, httpjson.Obj{"message": fmt.Sprintf("Error on storing data: %s", err)})
+}
+
+func (s *PushServer) store(metric metrics.Metrics) error {
+	// подпись пересчитывается клиентом агента перед отправкой
+	metric.Hash = ""
+	return s.storage.Store(metric)
+}
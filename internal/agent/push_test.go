@@ -0,0 +1,86 @@
+package agent
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/factory/storage/memory"
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/metrics"
+)
+
+func TestPushServer(t *testing.T) {
+	tests := []struct {
+		name        string
+		path        string
+		contentType string
+		body        string
+		wantCode    int
+		want        map[string]metrics.Metrics
+	}{
+		{
+			name:        "Single metric",
+			path:        "/update",
+			contentType: "application/json",
+			body:        `{"id":"QueueDepth","type":"gauge","value":3,"hash":"stale","labels":{"queue":"emails"}}`,
+			wantCode:    http.StatusOK,
+			want: map[string]metrics.Metrics{
+				`QueueDepth{queue="emails"}`: *metrics.NewGauge("QueueDepth", 3).WithLabels(map[string]string{"queue": "emails"}),
+			},
+		},
+		{
+			name:        "Batch",
+			path:        "/updates/",
+			contentType: "application/json",
+			body:        `[{"id":"Jobs","type":"counter","delta":2},{"id":"Jobs","type":"counter","delta":3}]`,
+			wantCode:    http.StatusOK,
+			want: map[string]metrics.Metrics{
+				"Jobs": *metrics.NewCounter("Jobs", 5),
+			},
+		},
+		{
+			name:        "Batch with an invalid metric is rejected",
+			path:        "/updates",
+			contentType: "application/json",
+			body:        `[{"id":"Jobs","type":"counter","delta":2},{"id":"Jobs","type":"counter"}]`,
+			wantCode:    http.StatusBadRequest,
+			want:        map[string]metrics.Metrics{},
+		},
+		{
+			name:        "Unsupported type",
+			path:        "/update",
+			contentType: "application/json",
+			body:        `{"id":"Jobs","type":"set","value":1}`,
+			wantCode:    http.StatusBadRequest,
+			want:        map[string]metrics.Metrics{},
+		},
+		{
+			name:        "Invalid Content-Type",
+			path:        "/update",
+			contentType: "text/plain",
+			body:        `{"id":"Jobs","type":"counter","delta":1}`,
+			wantCode:    http.StatusBadRequest,
+			want:        map[string]metrics.Metrics{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := memory.NewMemoryStorage()
+			s := NewPushServer("", storage)
+
+			r := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", tt.contentType)
+			rw := httptest.NewRecorder()
+			s.core.Handler.ServeHTTP(rw, r)
+
+			assert.Equal(t, tt.wantCode, rw.Code)
+			collection, err := storage.GetCollection()
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, collection)
+		})
+	}
+}
@@ -0,0 +1,291 @@
+// Package alerting периодически вычисляет правила оповещений по сохранённым метрикам.
+package alerting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/factory/storage"
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/metrics"
+)
+
+// Состояния оповещения
+const (
+	StateInactive = "inactive" // Условие не выполняется
+	StatePending  = "pending"  // Условие выполняется, но меньше чем For
+	StateFiring   = "firing"   // Оповещение сработало
+	StateResolved = "resolved" // Условие перестало выполняться после срабатывания
+)
+
+const (
+	notifyQueueSize = 100              // Количество оповещений, которые ждут доставки каждым получателем
+	notifyTimeout   = 30 * time.Second // Максимальное время доставки одного оповещения
+)
+
+// Alert текущее состояние правила оповещения
+type Alert struct {
+	Rule       Rule      `json:"rule"`
+	State      string    `json:"state"`
+	Value      float64   `json:"value"`
+	ActiveAt   time.Time `json:"active_at,omitempty"`
+	FiredAt    time.Time `json:"fired_at,omitempty"`
+	ResolvedAt time.Time `json:"resolved_at,omitempty"`
+}
+
+// transition идентифицирует переход в текущее состояние: повторное срабатывание правила
+// после разрешения отличается от предыдущего временем срабатывания
+func (a Alert) transition() string {
+	return fmt.Sprintf("%s|%d|%d", a.State, a.FiredAt.UnixNano(), a.ResolvedAt.UnixNano())
+}
+
+// Engine вычисляет правила оповещений по коллекции метрик из хранилища
+type Engine struct {
+	mu        sync.Mutex
+	storage   storage.CollectionGetter
+	tracker   *Tracker
+	rules     []Rule
+	alerts    map[string]*Alert
+	notifiers []Notifier
+	queues    []chan Alert // Очереди оповещений для каждого получателя
+	inflight  sync.WaitGroup
+	startedAt time.Time
+
+	undelivered map[string]string // Переход, о котором не удалось оповестить, по названию правила
+}
+
+// NewEngine создаёт движок оповещений. Правила отсутствия обновлений требуют tracker
+func NewEngine(storage storage.CollectionGetter, tracker *Tracker, rules []Rule, notifiers ...Notifier) *Engine {
+	alerts := make(map[string]*Alert, len(rules))
+	for _, rule := range rules {
+		alerts[rule.Name] = &Alert{
+			Rule:  rule,
+			State: StateInactive,
+		}
+	}
+
+	queues := make([]chan Alert, len(notifiers))
+	for i := range queues {
+		queues[i] = make(chan Alert, notifyQueueSize)
+	}
+
+	return &Engine{
+		mu:        sync.Mutex{},
+		storage:   storage,
+		tracker:   tracker,
+		rules:     rules,
+		alerts:    alerts,
+		notifiers: notifiers,
+		queues:    queues,
+		startedAt: time.Now(),
+
+		undelivered: make(map[string]string),
+	}
+}
+
+// Run запускает вычисление правил раз в какой-то интервал и доставку оповещений.
+// Каждый получатель доставляет оповещения в своей горутине, чтобы недоступный получатель
+// не задерживал вычисление правил
+func (e *Engine) Run(ctx context.Context, interval time.Duration) {
+	log.Info().Int("rules", len(e.rules)).Dur("interval", interval).Msg("Alerting engine started")
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for i, notifier := range e.notifiers {
+		wg.Add(1)
+		go func(notifier Notifier, queue <-chan Alert) {
+			defer wg.Done()
+			e.deliver(ctx, notifier, queue)
+		}(notifier, e.queues[i])
+	}
+
+	evaluateInterval := time.NewTicker(interval)
+	defer evaluateInterval.Stop()
+	for {
+		select {
+		case now := <-evaluateInterval.C:
+			if err := e.Evaluate(ctx, now); err != nil {
+				log.Error().Err(err).Msg("Evaluating alert rules")
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Evaluate вычисляет все правила на момент времени now и ставит в очередь оповещения о срабатывании
+// и разрешении правил. Оповещение отправляется только при смене состояния, а если доставить его не удалось,
+// то повторяется на следующих вычислениях, пока состояние не изменится
+func (e *Engine) Evaluate(_ context.Context, now time.Time) error {
+	collection, err := e.storage.GetCollection()
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	var notify []Alert
+	for _, rule := range e.rules {
+		alert := e.alerts[rule.Name]
+
+		var active bool
+		if rule.IsAbsence() {
+			active = e.evaluateAbsence(alert, now)
+		} else {
+			value, found := lookup(collection, rule)
+			if found {
+				alert.Value = value
+			}
+			active = found && rule.Matches(value)
+		}
+		changed := e.transit(alert, active, now)
+
+		retry, undelivered := e.undelivered[rule.Name]
+		delete(e.undelivered, rule.Name)
+		notifiable := alert.State == StateFiring || alert.State == StateResolved
+		if notifiable && (changed || undelivered && retry == alert.transition()) {
+			notify = append(notify, *alert)
+		}
+	}
+	e.mu.Unlock()
+
+	for _, alert := range notify {
+		for _, queue := range e.queues {
+			e.inflight.Add(1)
+			select {
+			case queue <- alert:
+			default:
+				e.inflight.Done()
+				log.Warn().Str("rule", alert.Rule.Name).Msg("Alert notification queue is full")
+				e.markUndelivered(alert)
+			}
+		}
+	}
+
+	return nil
+}
+
+// deliver отправляет оповещения из очереди, пока не завершится контекст
+func (e *Engine) deliver(ctx context.Context, notifier Notifier, queue <-chan Alert) {
+	for {
+		select {
+		case alert := <-queue:
+			notifyCtx, cancel := context.WithTimeout(ctx, notifyTimeout)
+			if err := notifier.Notify(notifyCtx, alert); err != nil {
+				log.Error().Err(err).Str("rule", alert.Rule.Name).Msg("Notifying about alert")
+				e.markUndelivered(alert)
+			}
+			cancel()
+			e.inflight.Done()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// markUndelivered запоминает переход, чтобы повторить оповещение на следующем вычислении
+func (e *Engine) markUndelivered(alert Alert) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.undelivered[alert.Rule.Name] = alert.transition()
+}
+
+// Alerts возвращает копию состояний всех правил, отсортированную по названию
+func (e *Engine) Alerts() []Alert {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	alerts := make([]Alert, 0, len(e.alerts))
+	for _, alert := range e.alerts {
+		alerts = append(alerts, *alert)
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		return alerts[i].Rule.Name < alerts[j].Rule.Name
+	})
+
+	return alerts
+}
+
+// transit меняет состояние оповещения и сообщает, изменилось ли оно
+func (e *Engine) transit(alert *Alert, active bool, now time.Time) bool {
+	previous := alert.State
+
+	switch {
+	case active && (alert.State == StateInactive || alert.State == StateResolved):
+		alert.State = StatePending
+		alert.ActiveAt = now
+		alert.FiredAt = time.Time{}
+		alert.ResolvedAt = time.Time{}
+		if alert.Rule.For.Duration == 0 {
+			alert.State = StateFiring
+			alert.FiredAt = now
+		}
+	case active && alert.State == StatePending:
+		if now.Sub(alert.ActiveAt) >= alert.Rule.For.Duration {
+			alert.State = StateFiring
+			alert.FiredAt = now
+		}
+	case !active && alert.State == StatePending:
+		alert.State = StateInactive
+		alert.ActiveAt = time.Time{}
+	case !active && alert.State == StateFiring:
+		alert.State = StateResolved
+		alert.ResolvedAt = now
+	}
+
+	if previous != alert.State {
+		log.Info().
+			Str("rule", alert.Rule.Name).
+			Str("from", previous).
+			Str("to", alert.State).
+			Float64("value", alert.Value).
+			Msg("Alert state changed")
+	}
+
+	return previous != alert.State
+}
+
+// evaluateAbsence сохраняет в alert.Value количество секунд с последнего обновления метрик правила.
+// Если метрики ещё ни разу не обновлялись, отсчёт идёт от запуска движка
+func (e *Engine) evaluateAbsence(alert *Alert, now time.Time) bool {
+	lastUpdated := e.startedAt
+	if e.tracker != nil {
+		var latest time.Time
+		var ok bool
+		if alert.Rule.MetricID == AllMetrics {
+			latest, ok = e.tracker.LatestWithLabels(alert.Rule.Labels)
+		} else {
+			latest, ok = e.tracker.Latest(alert.Rule.Metrics()...)
+		}
+		if ok {
+			lastUpdated = latest
+		}
+	}
+
+	silence := now.Sub(lastUpdated)
+	alert.Value = silence.Seconds()
+
+	return silence > alert.Rule.Window.Duration
+}
+
+func lookup(collection map[string]metrics.Metrics, rule Rule) (float64, bool) {
+	metric, ok := collection[metrics.Key(rule.MetricID, rule.Labels)]
+	if !ok || metric.Type != rule.MetricType {
+		return 0, false
+	}
+
+	value, err := metric.Float()
+	if err != nil {
+		if !errors.Is(err, metrics.ErrNoValue) {
+			log.Warn().Err(err).Str("rule", rule.Name).Msg("Reading metric value")
+		}
+		return 0, false
+	}
+
+	return value, true
+}
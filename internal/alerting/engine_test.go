@@ -0,0 +1,309 @@
+package alerting
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/factory/storage/memory"
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/metrics"
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/types"
+)
+
+func TestEngine_Evaluate(t *testing.T) {
+	rule := Rule{
+		Name:       "HighHeapAlloc",
+		MetricID:   "HeapAlloc",
+		MetricType: metrics.StringGaugeType,
+		Operator:   OperatorGreater,
+		Threshold:  100,
+		For:        types.Duration{Duration: time.Minute},
+	}
+	start := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		value float64
+		after time.Duration
+		want  string
+	}{
+		{name: "below threshold", value: 50, after: 0, want: StateInactive},
+		{name: "above threshold", value: 150, after: 10 * time.Second, want: StatePending},
+		{name: "still pending", value: 200, after: 30 * time.Second, want: StatePending},
+		{name: "firing after for", value: 200, after: 70 * time.Second, want: StateFiring},
+		{name: "still firing", value: 300, after: 80 * time.Second, want: StateFiring},
+		{name: "resolved", value: 10, after: 90 * time.Second, want: StateResolved},
+		{name: "pending again", value: 150, after: 100 * time.Second, want: StatePending},
+		{name: "back to inactive", value: 10, after: 110 * time.Second, want: StateInactive},
+	}
+
+	storage := memory.NewMemoryStorage()
+	engine := NewEngine(storage, nil, []Rule{rule})
+
+	for _, tt := range tests {
+		require.NoError(t, storage.Store(*metrics.NewGauge("HeapAlloc", tt.value)))
+		require.NoError(t, engine.Evaluate(context.Background(), start.Add(tt.after)))
+
+		alerts := engine.Alerts()
+		require.Len(t, alerts, 1)
+		assert.Equalf(t, tt.want, alerts[0].State, tt.name)
+		assert.Equalf(t, tt.value, alerts[0].Value, tt.name)
+	}
+}
+
+func TestEngine_EvaluateWithoutFor(t *testing.T) {
+	rule := Rule{
+		Name:       "TooManyPolls",
+		MetricID:   "PollCount",
+		MetricType: metrics.StringCounterType,
+		Operator:   OperatorGreaterOrEqual,
+		Threshold:  3,
+	}
+
+	storage := memory.NewMemoryStorage()
+	engine := NewEngine(storage, nil, []Rule{rule})
+	now := time.Now()
+
+	require.NoError(t, engine.Evaluate(context.Background(), now))
+	assert.Equal(t, StateInactive, engine.Alerts()[0].State, "no metric yet")
+
+	require.NoError(t, storage.Store(*metrics.NewCounter("PollCount", 3)))
+	require.NoError(t, engine.Evaluate(context.Background(), now))
+	assert.Equal(t, StateFiring, engine.Alerts()[0].State)
+	assert.Equal(t, now, engine.Alerts()[0].FiredAt)
+}
+
+func TestEngine_EvaluateAbsence(t *testing.T) {
+	rules := []Rule{
+		{
+			Name:     "AgentIsDown",
+			Kind:     KindAbsence,
+			MetricID: AllMetrics,
+			Window:   types.Duration{Duration: time.Minute},
+		},
+		{
+			Name:      "MemoryIsNotReported",
+			Kind:      KindAbsence,
+			MetricIDs: []string{"TotalMemory", "FreeMemory"},
+			Window:    types.Duration{Duration: 30 * time.Second},
+		},
+	}
+	start := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	tracker := NewTracker()
+	engine := NewEngine(memory.NewMemoryStorage(), tracker, rules)
+
+	tracker.Touch(start, "PollCount", "TotalMemory", "FreeMemory")
+	require.NoError(t, engine.Evaluate(context.Background(), start.Add(20*time.Second)))
+	alerts := engine.Alerts()
+	assert.Equal(t, StateInactive, alerts[0].State)
+	assert.Equal(t, StateInactive, alerts[1].State)
+
+	tracker.Touch(start.Add(30*time.Second), "PollCount")
+	require.NoError(t, engine.Evaluate(context.Background(), start.Add(40*time.Second)))
+	alerts = engine.Alerts()
+	assert.Equal(t, StateInactive, alerts[0].State)
+	assert.Equal(t, StateFiring, alerts[1].State)
+	assert.Equal(t, float64(40), alerts[1].Value)
+
+	require.NoError(t, engine.Evaluate(context.Background(), start.Add(100*time.Second)))
+	alerts = engine.Alerts()
+	assert.Equal(t, StateFiring, alerts[0].State)
+
+	tracker.Touch(start.Add(110*time.Second), "PollCount", "TotalMemory")
+	require.NoError(t, engine.Evaluate(context.Background(), start.Add(120*time.Second)))
+	alerts = engine.Alerts()
+	assert.Equal(t, StateResolved, alerts[0].State)
+	assert.Equal(t, StateResolved, alerts[1].State)
+}
+
+func TestEngine_EvaluateAbsenceWithLabels(t *testing.T) {
+	rules := []Rule{
+		{
+			Name:     "AgentAIsDown",
+			Kind:     KindAbsence,
+			MetricID: AllMetrics,
+			Labels:   types.Labels{"agent": "a"},
+			Window:   types.Duration{Duration: time.Minute},
+		},
+		{
+			Name:     "AgentBIsDown",
+			Kind:     KindAbsence,
+			MetricID: AllMetrics,
+			Labels:   types.Labels{"agent": "b"},
+			Window:   types.Duration{Duration: time.Minute},
+		},
+	}
+	start := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	agentA := metrics.Key("PollCount", map[string]string{"agent": "a", "host": "web-1"})
+	agentB := metrics.Key("PollCount", map[string]string{"agent": "b", "host": "web-2"})
+
+	tracker := NewTracker()
+	engine := NewEngine(memory.NewMemoryStorage(), tracker, rules)
+
+	tracker.Touch(start, agentA, agentB)
+	tracker.Touch(start.Add(90*time.Second), agentB)
+	require.NoError(t, engine.Evaluate(context.Background(), start.Add(100*time.Second)))
+	alerts := engine.Alerts()
+	assert.Equal(t, StateFiring, alerts[0].State, "agent a stopped reporting")
+	assert.Equal(t, float64(100), alerts[0].Value)
+	assert.Equal(t, StateInactive, alerts[1].State)
+}
+
+func TestRule_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    Rule
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			rule: Rule{Name: "a", MetricID: "Alloc", MetricType: metrics.StringGaugeType, Operator: OperatorLess},
+		},
+		{
+			name:    "empty name",
+			rule:    Rule{MetricID: "Alloc", MetricType: metrics.StringGaugeType, Operator: OperatorLess},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported type",
+			rule:    Rule{Name: "a", MetricID: "Alloc", MetricType: "something", Operator: OperatorLess},
+			wantErr: true,
+		},
+		{
+			name: "valid absence",
+			rule: Rule{Name: "a", Kind: KindAbsence, MetricID: AllMetrics, Window: types.Duration{Duration: time.Minute}},
+		},
+		{
+			name:    "absence without window",
+			rule:    Rule{Name: "a", Kind: KindAbsence, MetricID: "Alloc"},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported operator",
+			rule:    Rule{Name: "a", MetricID: "Alloc", MetricType: metrics.StringGaugeType, Operator: "=>"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.rule.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+type recordingNotifier struct {
+	failures int
+	states   []string
+}
+
+func (n *recordingNotifier) Notify(_ context.Context, alert Alert) error {
+	if n.failures > 0 {
+		n.failures--
+		return errors.New("delivery failed")
+	}
+	n.states = append(n.states, alert.State)
+	return nil
+}
+
+func TestEngine_EvaluateNotifiesOnTransitions(t *testing.T) {
+	rule := Rule{
+		Name:       "HighHeapAlloc",
+		MetricID:   "HeapAlloc",
+		MetricType: metrics.StringGaugeType,
+		Operator:   OperatorGreater,
+		Threshold:  100,
+	}
+	start := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	storage := memory.NewMemoryStorage()
+	notifier := &recordingNotifier{}
+	engine := NewEngine(storage, nil, []Rule{rule}, notifier)
+	startDelivery(t, engine)
+
+	for i, value := range []float64{150, 200, 10, 10, 10} {
+		require.NoError(t, storage.Store(*metrics.NewGauge("HeapAlloc", value)))
+		require.NoError(t, engine.Evaluate(context.Background(), start.Add(time.Duration(i)*time.Second)))
+		engine.inflight.Wait()
+	}
+	assert.Equal(t, []string{StateFiring, StateResolved}, notifier.states, "only state changes are notified")
+
+	notifier.failures = 1
+	for i, value := range []float64{150, 150, 150} {
+		require.NoError(t, storage.Store(*metrics.NewGauge("HeapAlloc", value)))
+		require.NoError(t, engine.Evaluate(context.Background(), start.Add(time.Duration(10+i)*time.Second)))
+		engine.inflight.Wait()
+	}
+	assert.Equal(t, []string{StateFiring, StateResolved, StateFiring}, notifier.states, "a failed notification is retried on the next evaluation")
+}
+
+type blockingNotifier struct {
+	release chan struct{}
+}
+
+func (n *blockingNotifier) Notify(ctx context.Context, _ Alert) error {
+	select {
+	case <-n.release:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestEngine_EvaluateDoesNotWaitForNotifiers(t *testing.T) {
+	rules := []Rule{
+		{Name: "HighHeapAlloc", MetricID: "HeapAlloc", MetricType: metrics.StringGaugeType, Operator: OperatorGreater, Threshold: 100},
+		{Name: "HighCPU", MetricID: "CPU", MetricType: metrics.StringGaugeType, Operator: OperatorGreater, Threshold: 90},
+	}
+	start := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	storage := memory.NewMemoryStorage()
+	notifier := &blockingNotifier{release: make(chan struct{})}
+	engine := NewEngine(storage, nil, rules, notifier)
+	startDelivery(t, engine)
+
+	require.NoError(t, storage.Store(*metrics.NewGauge("HeapAlloc", 150)))
+	require.NoError(t, storage.Store(*metrics.NewGauge("CPU", 95)))
+	evaluated := make(chan error)
+	go func() {
+		evaluated <- engine.Evaluate(context.Background(), start)
+	}()
+	select {
+	case err := <-evaluated:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("evaluation waits for a blocked notifier")
+	}
+
+	require.NoError(t, storage.Store(*metrics.NewGauge("CPU", 10)))
+	require.NoError(t, engine.Evaluate(context.Background(), start.Add(time.Second)))
+	alerts := engine.Alerts()
+	assert.Equal(t, StateResolved, alerts[0].State, "rules are evaluated while notifications are pending")
+	assert.Equal(t, StateFiring, alerts[1].State)
+
+	close(notifier.release)
+	engine.inflight.Wait()
+}
+
+// startDelivery запускает доставку оповещений движка до конца теста
+func startDelivery(t *testing.T, engine *Engine) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		engine.Run(ctx, time.Hour)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
@@ -0,0 +1,155 @@
+package alerting
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/metrics"
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/types"
+)
+
+// Операторы сравнения значения метрики с порогом
+const (
+	OperatorGreater        = ">"
+	OperatorGreaterOrEqual = ">="
+	OperatorLess           = "<"
+	OperatorLessOrEqual    = "<="
+	OperatorEqual          = "=="
+	OperatorNotEqual       = "!="
+)
+
+// Виды правил оповещения
+const (
+	KindThreshold = "threshold" // Значение метрики сравнивается с порогом
+	KindAbsence   = "absence"   // Метрики не обновлялись дольше окна Window
+)
+
+// AllMetrics в качестве MetricID правила отсутствия означает все метрики, которые получал сервер.
+// Если у правила заданы метки, то учитываются только метрики с этими метками, например, метрики одного агента
+const AllMetrics = "*"
+
+// Rule декларативное правило оповещения
+type Rule struct {
+	Name       string         `json:"name"`                 // Уникальное название правила
+	Kind       string         `json:"kind,omitempty"`       // Вид правила, по умолчанию threshold
+	MetricID   string         `json:"metric_id"`            // Название метрики
+	MetricIDs  []string       `json:"metric_ids,omitempty"` // (absence) Набор метрик, например, всех метрик одного агента
+	MetricType string         `json:"metric_type"`          // Тип метрики
+	Labels     types.Labels   `json:"labels,omitempty"`     // Метки метрики
+	Operator   string         `json:"operator"`             // (threshold) Оператор сравнения
+	Threshold  float64        `json:"threshold"`            // (threshold) Пороговое значение
+	Window     types.Duration `json:"window,omitempty"`     // (absence) Сколько метрики могут не обновляться
+	For        types.Duration `json:"for"`                  // Сколько условие должно выполняться, прежде чем оповещение сработает
+}
+
+// LoadRules читает правила оповещений из JSON файла
+func LoadRules(path string) ([]Rule, error) {
+	jsonBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read alert rules file: %w", err)
+	}
+
+	var rules []Rule
+	if err = json.Unmarshal(jsonBytes, &rules); err != nil {
+		return nil, fmt.Errorf("parse alert rules: %w", err)
+	}
+
+	names := make(map[string]struct{}, len(rules))
+	for i, rule := range rules {
+		if rule.Kind == "" {
+			rules[i].Kind = KindThreshold
+		}
+		if err = rule.Validate(); err != nil {
+			return nil, fmt.Errorf("rule [%s]: %w", rule.Name, err)
+		}
+		if _, ok := names[rule.Name]; ok {
+			return nil, fmt.Errorf("rule [%s]: duplicate name", rule.Name)
+		}
+		names[rule.Name] = struct{}{}
+	}
+
+	return rules, nil
+}
+
+// Validate проверяет корректность полей правила
+func (r *Rule) Validate() error {
+	if r.Name == "" {
+		return errors.New("empty rule name")
+	}
+	if r.For.Duration < 0 {
+		return errors.New("negative for duration")
+	}
+
+	switch r.Kind {
+	case "", KindThreshold:
+	case KindAbsence:
+		if r.MetricID == "" && len(r.MetricIDs) == 0 {
+			return errors.New("empty metric id")
+		}
+		if r.Window.Duration <= 0 {
+			return errors.New("absence window must be positive")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported rule kind %q", r.Kind)
+	}
+
+	if r.MetricID == "" {
+		return errors.New("empty metric id")
+	}
+	if r.MetricType != metrics.StringCounterType && r.MetricType != metrics.StringGaugeType {
+		return errors.New("unsupported metric type")
+	}
+	switch r.Operator {
+	case OperatorGreater, OperatorGreaterOrEqual, OperatorLess, OperatorLessOrEqual, OperatorEqual, OperatorNotEqual:
+	default:
+		return fmt.Errorf("unsupported operator %q", r.Operator)
+	}
+
+	return nil
+}
+
+// Matches сравнивает значение с порогом правила
+func (r *Rule) Matches(value float64) bool {
+	switch r.Operator {
+	case OperatorGreater:
+		return value > r.Threshold
+	case OperatorGreaterOrEqual:
+		return value >= r.Threshold
+	case OperatorLess:
+		return value < r.Threshold
+	case OperatorLessOrEqual:
+		return value <= r.Threshold
+	case OperatorEqual:
+		return value == r.Threshold
+	case OperatorNotEqual:
+		return value != r.Threshold
+	}
+
+	return false
+}
+
+// IsAbsence сообщает, является ли правило правилом отсутствия обновлений
+func (r *Rule) IsAbsence() bool {
+	return r.Kind == KindAbsence
+}
+
+// Metrics возвращает идентификаторы метрик правила с учётом меток. Для AllMetrics возвращается пустой список,
+// а метрики отбираются по меткам правила
+func (r *Rule) Metrics() []string {
+	if r.MetricID == AllMetrics {
+		return nil
+	}
+
+	keys := make([]string, 0, len(r.MetricIDs)+1)
+	if r.MetricID != "" {
+		keys = append(keys, metrics.Key(r.MetricID, r.Labels))
+	}
+	for _, id := range r.MetricIDs {
+		keys = append(keys, metrics.Key(id, r.Labels))
+	}
+
+	return keys
+}
@@ -0,0 +1,111 @@
+package alerting
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/metrics"
+)
+
+// Tracker запоминает время последнего обновления каждой метрики
+type Tracker struct {
+	mu      sync.RWMutex
+	updated map[string]time.Time
+	labels  map[string]map[string]string // Метки метрик, разобранные из идентификаторов
+}
+
+func NewTracker() *Tracker {
+	return &Tracker{
+		mu:      sync.RWMutex{},
+		updated: make(map[string]time.Time),
+		labels:  make(map[string]map[string]string),
+	}
+}
+
+// Touch отмечает метрики обновлёнными в момент времени now
+func (t *Tracker) Touch(now time.Time, ids ...string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, id := range ids {
+		if _, ok := t.updated[id]; !ok {
+			_, labels, err := metrics.ParseKey(id)
+			if err != nil {
+				log.Warn().Err(err).Msg("Tracking metric labels")
+			}
+			t.labels[id] = labels
+		}
+		t.updated[id] = now
+	}
+}
+
+// LastUpdated возвращает время последнего обновления метрики
+func (t *Tracker) LastUpdated(id string) (time.Time, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	updated, ok := t.updated[id]
+	return updated, ok
+}
+
+// Latest возвращает самое позднее время обновления среди метрик. Пустой список означает все метрики
+func (t *Tracker) Latest(ids ...string) (time.Time, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var latest time.Time
+	var found bool
+	check := func(updated time.Time) {
+		if !found || updated.After(latest) {
+			latest = updated
+			found = true
+		}
+	}
+
+	if len(ids) == 0 {
+		for _, updated := range t.updated {
+			check(updated)
+		}
+		return latest, found
+	}
+
+	for _, id := range ids {
+		if updated, ok := t.updated[id]; ok {
+			check(updated)
+		}
+	}
+
+	return latest, found
+}
+
+// LatestWithLabels возвращает самое позднее время обновления среди метрик, у которых есть все метки labels.
+// Пустой набор меток означает все метрики
+func (t *Tracker) LatestWithLabels(labels map[string]string) (time.Time, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var latest time.Time
+	var found bool
+	for id, updated := range t.updated {
+		if !hasLabels(t.labels[id], labels) {
+			continue
+		}
+		if !found || updated.After(latest) {
+			latest = updated
+			found = true
+		}
+	}
+
+	return latest, found
+}
+
+func hasLabels(labels map[string]string, subset map[string]string) bool {
+	for name, value := range subset {
+		if actual, ok := labels[name]; !ok || actual != value {
+			return false
+		}
+	}
+	return true
+}
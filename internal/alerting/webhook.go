@@ -0,0 +1,162 @@
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/rs/zerolog/log"
+
+	"github.com/PostScripton/go-metrics-and-alerting-collection/pkg/hashing"
+)
+
+// SignatureHeader заголовок, в котором передаётся HMAC подпись тела запроса в hex
+const SignatureHeader = "X-Signature"
+
+// Notifier доставляет оповещения о смене их состояния
+type Notifier interface {
+	Notify(ctx context.Context, alert Alert) error
+}
+
+// WebhookPayload тело запроса, которое отправляется на вебхук
+type WebhookPayload struct {
+	Rule       string            `json:"rule"`
+	Kind       string            `json:"kind"`
+	MetricID   string            `json:"metric_id"`
+	MetricIDs  []string          `json:"metric_ids,omitempty"`
+	MetricType string            `json:"metric_type"`
+	Labels     map[string]string `json:"labels,omitempty"`
+	Value      float64           `json:"value"`
+	State      string            `json:"state"`
+	ActiveAt   time.Time         `json:"active_at"`
+	FiredAt    time.Time         `json:"fired_at"`
+	ResolvedAt time.Time         `json:"resolved_at"`
+}
+
+// WebhookNotifier отправляет оповещения POST-запросом в JSON формате на указанные адреса
+type WebhookNotifier struct {
+	urls   []string
+	client *resty.Client
+	signer hashing.Signer
+	key    string
+
+	mu   sync.Mutex
+	sent map[string]string // Последний доставленный переход по адресу и названию правила
+}
+
+var _ Notifier = (*WebhookNotifier)(nil)
+
+// NewWebhookNotifier создаёт вебхук. Если передан ключ, то тело запроса подписывается с помощью signer
+func NewWebhookNotifier(urls []string, signer hashing.Signer, key string) *WebhookNotifier {
+	return &WebhookNotifier{
+		urls: urls,
+		client: resty.New().
+			SetTimeout(5 * time.Second).
+			SetRetryCount(3).
+			SetRetryWaitTime(500 * time.Millisecond).
+			SetRetryMaxWaitTime(10 * time.Second).
+			AddRetryCondition(func(res *resty.Response, err error) bool {
+				return err != nil || res == nil || res.StatusCode() >= http.StatusInternalServerError
+			}),
+		signer: signer,
+		key:    key,
+		sent:   make(map[string]string),
+	}
+}
+
+// SetRetry задаёт количество повторных попыток и границы экспоненциальной задержки между ними
+func (w *WebhookNotifier) SetRetry(count int, waitTime time.Duration, maxWaitTime time.Duration) *WebhookNotifier {
+	w.client.
+		SetRetryCount(count).
+		SetRetryWaitTime(waitTime).
+		SetRetryMaxWaitTime(maxWaitTime)
+
+	return w
+}
+
+// Notify отправляет оповещение на все адреса. Повторное оповещение о том же переходе не отправляется,
+// а новое срабатывание после разрешения отправляется, даже если не удалось доставить разрешение
+func (w *WebhookNotifier) Notify(ctx context.Context, alert Alert) error {
+	if alert.State != StateFiring && alert.State != StateResolved {
+		return nil
+	}
+
+	body, err := json.Marshal(WebhookPayload{
+		Rule:       alert.Rule.Name,
+		Kind:       alert.Rule.Kind,
+		MetricID:   alert.Rule.MetricID,
+		MetricIDs:  alert.Rule.MetricIDs,
+		MetricType: alert.Rule.MetricType,
+		Labels:     alert.Rule.Labels,
+		Value:      alert.Value,
+		State:      alert.State,
+		ActiveAt:   alert.ActiveAt,
+		FiredAt:    alert.FiredAt,
+		ResolvedAt: alert.ResolvedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	var failed []string
+	for _, url := range w.urls {
+		if w.alreadySent(url, alert) {
+			log.Debug().Str("url", url).Str("rule", alert.Rule.Name).Msg("Alert notification already sent")
+			continue
+		}
+
+		if err = w.send(ctx, url, body); err != nil {
+			log.Warn().Err(err).Str("url", url).Str("rule", alert.Rule.Name).Msg("Alert notification failed")
+			failed = append(failed, url)
+			continue
+		}
+
+		w.markSent(url, alert)
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("alert notification [%s] failed for: %s", alert.Rule.Name, strings.Join(failed, ", "))
+	}
+
+	return nil
+}
+
+func (w *WebhookNotifier) alreadySent(url string, alert Alert) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.sent[url+"|"+alert.Rule.Name] == alert.transition()
+}
+
+func (w *WebhookNotifier) markSent(url string, alert Alert) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.sent[url+"|"+alert.Rule.Name] = alert.transition()
+}
+
+func (w *WebhookNotifier) send(ctx context.Context, url string, body []byte) error {
+	req := w.client.R().
+		SetContext(ctx).
+		SetHeader("Content-Type", "application/json").
+		SetBody(body)
+	if w.key != "" && w.signer != nil {
+		req.SetHeader(SignatureHeader, w.signer.HashToHex(w.signer.Hash(string(body), w.key)))
+	}
+
+	res, err := req.Post(url)
+	if err != nil {
+		return fmt.Errorf("send request error: %w", err)
+	}
+
+	if res.StatusCode() < http.StatusOK || res.StatusCode() >= http.StatusMultipleChoices {
+		return fmt.Errorf("unexpected status code: %d", res.StatusCode())
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/metrics"
+	"github.com/PostScripton/go-metrics-and-alerting-collection/pkg/hashing/hmac"
+)
+
+type receiver struct {
+	mu       sync.Mutex
+	failures int
+	payloads []WebhookPayload
+	hashes   []string
+}
+
+func (rc *receiver) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if rc.failures > 0 {
+		rc.failures--
+		rw.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	body, _ := io.ReadAll(r.Body)
+	var payload WebhookPayload
+	_ = json.Unmarshal(body, &payload)
+
+	rc.payloads = append(rc.payloads, payload)
+	rc.hashes = append(rc.hashes, r.Header.Get(SignatureHeader))
+	rw.WriteHeader(http.StatusOK)
+}
+
+func firingAlert() Alert {
+	return Alert{
+		Rule: Rule{
+			Name:       "HighHeapAlloc",
+			MetricID:   "HeapAlloc",
+			MetricType: metrics.StringGaugeType,
+			Operator:   OperatorGreater,
+			Threshold:  100,
+		},
+		State:    StateFiring,
+		Value:    150,
+		ActiveAt: time.Now(),
+		FiredAt:  time.Now(),
+	}
+}
+
+func TestWebhookNotifier_Notify(t *testing.T) {
+	rc := &receiver{failures: 2}
+	ts := httptest.NewServer(rc)
+	defer ts.Close()
+
+	key := "secret"
+	signer := hmac.NewHmacSigner()
+	notifier := NewWebhookNotifier([]string{ts.URL}, signer, key).SetRetry(3, time.Millisecond, 5*time.Millisecond)
+
+	alert := firingAlert()
+	require.NoError(t, notifier.Notify(context.Background(), alert))
+	require.NoError(t, notifier.Notify(context.Background(), alert), "repeated firing is deduplicated")
+
+	alert.State = StateResolved
+	alert.ResolvedAt = time.Now()
+	require.NoError(t, notifier.Notify(context.Background(), alert))
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	require.Len(t, rc.payloads, 2)
+	assert.Equal(t, StateFiring, rc.payloads[0].State)
+	assert.Equal(t, "HighHeapAlloc", rc.payloads[0].Rule)
+	assert.Equal(t, "HeapAlloc", rc.payloads[0].MetricID)
+	assert.Equal(t, float64(150), rc.payloads[0].Value)
+	assert.Equal(t, StateResolved, rc.payloads[1].State)
+
+	body, err := json.Marshal(rc.payloads[1])
+	require.NoError(t, err)
+	assert.True(t, signer.ValidHash(signer.Hash(string(body), key), rc.hashes[1]))
+}
+
+func TestWebhookNotifier_NotifyFailed(t *testing.T) {
+	rc := &receiver{failures: 10}
+	ts := httptest.NewServer(rc)
+	defer ts.Close()
+
+	notifier := NewWebhookNotifier([]string{ts.URL}, nil, "").SetRetry(2, time.Millisecond, 5*time.Millisecond)
+
+	assert.Error(t, notifier.Notify(context.Background(), firingAlert()))
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	assert.Equal(t, 7, rc.failures, "one attempt and two retries")
+	assert.Empty(t, rc.payloads)
+}
+
+func TestWebhookNotifier_NotifyFiringAfterFailedResolve(t *testing.T) {
+	rc := &receiver{}
+	ts := httptest.NewServer(rc)
+	defer ts.Close()
+
+	notifier := NewWebhookNotifier([]string{ts.URL}, nil, "").SetRetry(0, time.Millisecond, time.Millisecond)
+
+	alert := firingAlert()
+	require.NoError(t, notifier.Notify(context.Background(), alert))
+
+	rc.mu.Lock()
+	rc.failures = 1
+	rc.mu.Unlock()
+	alert.State = StateResolved
+	alert.ResolvedAt = alert.FiredAt.Add(time.Minute)
+	assert.Error(t, notifier.Notify(context.Background(), alert))
+
+	alert.State = StateFiring
+	alert.FiredAt = alert.ResolvedAt.Add(time.Minute)
+	alert.ResolvedAt = time.Time{}
+	require.NoError(t, notifier.Notify(context.Background(), alert))
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	require.Len(t, rc.payloads, 2, "the new firing is not mistaken for the delivered one")
+	assert.Equal(t, StateFiring, rc.payloads[1].State)
+}
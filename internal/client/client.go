@@ -5,6 +5,7 @@ import (
 	"compress/gzip"
 	"crypto/rsa"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -18,12 +19,17 @@ import (
 	"github.com/PostScripton/go-metrics-and-alerting-collection/pkg/hashing/hmac"
 )
 
+// ErrRejected сервер окончательно отклонил запрос, и повторная отправка получит тот же ответ
+var ErrRejected = errors.New("request rejected by the server")
+
 // Client позволяет делать запрос на сервер
 type Client struct {
 	baseURI   string
 	client    *resty.Client
 	key       string
 	publicKey *rsa.PublicKey
+	labels    map[string]string
+	retry     RetryPolicy
 }
 
 func NewClient(baseURI string, timeout time.Duration, key string, cryptoKey string) *Client {
@@ -32,7 +38,7 @@ func NewClient(baseURI string, timeout time.Duration, key string, cryptoKey stri
 		log.Warn().Err(err).Msg("Failed to get public key from file")
 	}
 
-	return &Client{
+	c := &Client{
 		baseURI: baseURI,
 		client: resty.New().
 			SetBaseURL(baseURI).
@@ -40,6 +46,31 @@ func NewClient(baseURI string, timeout time.Duration, key string, cryptoKey stri
 		key:       key,
 		publicKey: publicKey,
 	}
+	c.setupRetry()
+	c.SetRetryPolicy(DefaultRetryPolicy())
+
+	return c
+}
+
+// SetLabels задаёт метки, которые добавляются к каждой отправляемой метрике.
+// Собственные метки метрики с тем же именем имеют приоритет
+func (c *Client) SetLabels(labels map[string]string) {
+	c.labels = labels
+}
+
+func (c *Client) applyLabels(metric *metrics.Metrics) {
+	if len(c.labels) == 0 {
+		return
+	}
+
+	labels := make(map[string]string, len(c.labels)+len(metric.Labels))
+	for name, value := range c.labels {
+		labels[name] = value
+	}
+	for name, value := range metric.Labels {
+		labels[name] = value
+	}
+	metric.WithLabels(labels)
 }
 
 // UpdateMetric обновляет метрику, передавая информацию в URI
@@ -51,7 +82,10 @@ func (c *Client) UpdateMetric(metricType string, name string, value string) erro
 		Msg("Updating metric")
 
 	uri := fmt.Sprintf("/update/%s/%s/%s", metricType, name, value)
-	res, err := c.client.R().SetHeader("Content-Type", "text/plain").Post(uri)
+	res, err := c.client.R().
+		SetHeader("Content-Type", "text/plain").
+		SetQueryParams(c.labels).
+		Post(uri)
 	if err != nil {
 		return fmt.Errorf("send request error: %w", err)
 	}
@@ -67,6 +101,7 @@ func (c *Client) UpdateMetric(metricType string, name string, value string) erro
 
 // UpdateMetricJSON обновляет метрику, передавая информацию через POST-запрос в JSON формате
 func (c *Client) UpdateMetricJSON(metric metrics.Metrics) error {
+	c.applyLabels(&metric)
 	if c.key != "" {
 		metric.Hash = metric.ToHexHash(hmac.NewHmacSigner(), c.key)
 	}
@@ -117,6 +152,7 @@ func (c *Client) UpdateMetricsBatchJSON(collection map[string]metrics.Metrics) e
 
 	var newCollection = make([]metrics.Metrics, 0, length)
 	for _, m := range collection {
+		c.applyLabels(&m)
 		if c.key != "" {
 			m.Hash = m.ToHexHash(hmac.NewHmacSigner(), c.key)
 		}
@@ -156,6 +192,9 @@ func (c *Client) UpdateMetricsBatchJSON(collection map[string]metrics.Metrics) e
 	if res.StatusCode() != http.StatusOK {
 		message := strings.Trim(string(res.Body()), "\n")
 		log.Warn().Int("status_code", res.StatusCode()).Str("message", message).Msg("Response")
+		if c.rejected(res.StatusCode()) {
+			return fmt.Errorf("%w: %d %s", ErrRejected, res.StatusCode(), message)
+		}
 		return fmt.Errorf(message)
 	}
 
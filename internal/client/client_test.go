@@ -0,0 +1,25 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/metrics"
+)
+
+func TestClient_ApplyLabels(t *testing.T) {
+	c := NewClient("https://test.com", time.Second, "", "")
+	c.SetLabels(map[string]string{"host": "web-1", "env": "prod"})
+
+	plain := metrics.NewGauge("Alloc", 1)
+	c.applyLabels(plain)
+	assert.Equal(t, map[string]string{"host": "web-1", "env": "prod"}, plain.Labels)
+
+	own := map[string]string{"mountpoint": "/", "env": "staging"}
+	labelled := metrics.NewGauge("DiskUsedBytes", 1).WithLabels(own)
+	c.applyLabels(labelled)
+	assert.Equal(t, map[string]string{"host": "web-1", "env": "staging", "mountpoint": "/"}, labelled.Labels)
+	assert.Equal(t, map[string]string{"mountpoint": "/", "env": "staging"}, own, "the source labels must not be modified")
+}
@@ -0,0 +1,182 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/rs/zerolog/log"
+)
+
+// RetryPolicy описывает повторные попытки запроса при временных сбоях сервера
+type RetryPolicy struct {
+	MaxAttempts    int           // Максимальное количество попыток вместе с первой
+	BaseDelay      time.Duration // Задержка перед первой повторной попыткой, дальше удваивается
+	MaxDelay       time.Duration // Максимальная задержка между попытками
+	Jitter         bool          // Добавлять ли случайный разброс к задержке
+	RetryableCodes []int         // Коды ответа, при которых запрос повторяется. Ошибки соединения повторяются всегда
+}
+
+const defaultRetryMaxAttempts = 3
+const defaultRetryBaseDelay = 100 * time.Millisecond
+const defaultRetryMaxDelay = 2 * time.Second
+
+// DefaultRetryPolicy возвращает политику, переживающую перезапуск сервера в пределах пары секунд
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: defaultRetryMaxAttempts,
+		BaseDelay:   defaultRetryBaseDelay,
+		MaxDelay:    defaultRetryMaxDelay,
+		Jitter:      true,
+		RetryableCodes: []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+	}
+}
+
+// SetRetryPolicy задаёт политику повторных попыток для всех запросов клиента
+func (c *Client) SetRetryPolicy(policy RetryPolicy) *Client {
+	if policy.MaxAttempts < 1 {
+		policy.MaxAttempts = 1
+	}
+	if policy.MaxDelay < policy.BaseDelay {
+		policy.MaxDelay = policy.BaseDelay
+	}
+
+	c.retry = policy
+	c.client.
+		SetRetryCount(policy.MaxAttempts - 1).
+		SetRetryWaitTime(policy.BaseDelay).
+		SetRetryMaxWaitTime(policy.MaxDelay)
+
+	return c
+}
+
+// setupRetry подключает политику к resty-клиенту. Условия читают c.retry, поэтому политику можно менять позже
+func (c *Client) setupRetry() {
+	c.client.
+		AddRetryCondition(func(res *resty.Response, err error) bool {
+			return c.shouldRetry(res, err)
+		}).
+		SetRetryAfter(func(_ *resty.Client, res *resty.Response) (time.Duration, error) {
+			return c.retryDelay(res)
+		}).
+		AddRetryHook(func(res *resty.Response, err error) {
+			event := log.Warn().Err(err)
+			if res != nil {
+				event = event.Int("attempt", res.Request.Attempt).Int("status_code", res.StatusCode())
+			}
+			event.Msg("Request failed, retrying")
+		})
+}
+
+// shouldRetry повторяет только запросы, которые точно не дошли до сервера, и ответы с кодами из политики.
+// После тайм-аута или обрыва соединения сервер мог уже сохранить пачку, и повтор учёл бы счётчики дважды
+func (c *Client) shouldRetry(res *resty.Response, err error) bool {
+	if err != nil {
+		return notSent(err)
+	}
+	if res == nil {
+		return false
+	}
+
+	return c.retryable(res.StatusCode())
+}
+
+func (c *Client) retryable(statusCode int) bool {
+	for _, code := range c.retry.RetryableCodes {
+		if statusCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+// rejected проверяет, что ответ с кодом 4xx не исправится повторной отправкой, например,
+// из-за неверной подписи или некорректной метрики
+func (c *Client) rejected(statusCode int) bool {
+	return statusCode >= http.StatusBadRequest && statusCode < http.StatusInternalServerError && !c.retryable(statusCode)
+}
+
+// notSent проверяет, что запрос не был отправлен: не удалось найти адрес сервера или подключиться к нему
+func notSent(err error) bool {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// retryDelay учитывает заголовок Retry-After. Если сервер просит подождать дольше MaxDelay,
+// повторные попытки прекращаются, чтобы не задерживать цикл отправки
+func (c *Client) retryDelay(res *resty.Response) (time.Duration, error) {
+	if res != nil && res.RawResponse != nil {
+		if delay, ok := parseRetryAfter(res.Header().Get("Retry-After"), time.Now()); ok {
+			if delay > c.retry.MaxDelay {
+				return 0, fmt.Errorf("server asked to retry after %s, which exceeds the max delay %s", delay, c.retry.MaxDelay)
+			}
+			if delay == 0 {
+				return c.retry.BaseDelay, nil
+			}
+			return delay, nil
+		}
+	}
+
+	attempt := 1
+	if res != nil {
+		attempt = res.Request.Attempt
+	}
+	return c.retry.backoff(attempt), nil
+}
+
+// backoff возвращает задержку перед повторной попыткой после attempt-й неудачной попытки
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	delay := float64(p.BaseDelay) * math.Exp2(float64(attempt-1))
+	if delay > float64(p.MaxDelay) {
+		delay = float64(p.MaxDelay)
+	}
+	if p.Jitter && delay > 0 {
+		// половина задержки фиксирована, вторая половина случайна
+		delay = delay/2 + rand.Float64()*delay/2
+	}
+
+	return time.Duration(delay)
+}
+
+// parseRetryAfter разбирает Retry-After в виде количества секунд или HTTP-даты
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	delay := date.Sub(now)
+	if delay < 0 {
+		delay = 0
+	}
+	return delay, true
+}
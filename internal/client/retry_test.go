@@ -0,0 +1,164 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/metrics"
+)
+
+func testRetryPolicy() RetryPolicy {
+	policy := DefaultRetryPolicy()
+	policy.BaseDelay = time.Millisecond
+	policy.MaxDelay = 10 * time.Millisecond
+	return policy
+}
+
+func TestClient_Retry(t *testing.T) {
+	tests := []struct {
+		name       string
+		responses  []int
+		retryAfter string
+		wantErr    bool
+		wantCalls  int32
+	}{
+		{
+			name:      "Recovers after server restart",
+			responses: []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusOK},
+			wantCalls: 3,
+		},
+		{
+			name:      "Gives up after max attempts",
+			responses: []int{http.StatusGatewayTimeout, http.StatusGatewayTimeout, http.StatusGatewayTimeout, http.StatusOK},
+			wantErr:   true,
+			wantCalls: 3,
+		},
+		{
+			name:      "Does not retry client errors",
+			responses: []int{http.StatusBadRequest, http.StatusOK},
+			wantErr:   true,
+			wantCalls: 1,
+		},
+		{
+			name:       "Respects short Retry-After",
+			responses:  []int{http.StatusTooManyRequests, http.StatusOK},
+			retryAfter: "0",
+			wantCalls:  2,
+		},
+		{
+			name:       "Stops when Retry-After exceeds max delay",
+			responses:  []int{http.StatusServiceUnavailable, http.StatusOK},
+			retryAfter: "120",
+			wantErr:    true,
+			wantCalls:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				call := atomic.AddInt32(&calls, 1)
+				code := tt.responses[call-1]
+				if code != http.StatusOK && tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				w.WriteHeader(code)
+			}))
+			defer server.Close()
+
+			c := NewClient(server.URL, time.Second, "", "").SetRetryPolicy(testRetryPolicy())
+
+			err := c.UpdateMetricsBatchJSON(map[string]metrics.Metrics{
+				"PollCount": *metrics.NewCounter("PollCount", 1),
+			})
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantCalls, atomic.LoadInt32(&calls))
+		})
+	}
+}
+
+func TestClient_RetryNetworkError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := server.URL
+	server.Close()
+
+	var retries int32
+	c := NewClient(url, time.Second, "", "").SetRetryPolicy(testRetryPolicy())
+	c.client.AddRetryHook(func(_ *resty.Response, _ error) {
+		atomic.AddInt32(&retries, 1)
+	})
+
+	err := c.UpdateMetric(metrics.StringGaugeType, "Alloc", "1")
+	require.Error(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&retries))
+}
+
+func TestClient_DoesNotRetryTimeout(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		// сервер получил пачку, но ответил позже тайм-аута клиента
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, 50*time.Millisecond, "", "").SetRetryPolicy(testRetryPolicy())
+
+	err := c.UpdateMetricsBatchJSON(map[string]metrics.Metrics{
+		"PollCount": *metrics.NewCounter("PollCount", 1),
+	})
+	require.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls), "a batch that may have been applied must not be sent twice")
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	assert.Equal(t, 100*time.Millisecond, policy.backoff(1))
+	assert.Equal(t, 200*time.Millisecond, policy.backoff(2))
+	assert.Equal(t, 400*time.Millisecond, policy.backoff(3))
+	assert.Equal(t, time.Second, policy.backoff(10))
+
+	policy.Jitter = true
+	for attempt := 1; attempt <= 5; attempt++ {
+		delay := policy.backoff(attempt)
+		full := RetryPolicy{BaseDelay: policy.BaseDelay, MaxDelay: policy.MaxDelay}.backoff(attempt)
+		assert.GreaterOrEqual(t, delay, full/2)
+		assert.LessOrEqual(t, delay, full)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{value: "", wantOK: false},
+		{value: "5", want: 5 * time.Second, wantOK: true},
+		{value: "-1", wantOK: false},
+		{value: "Sat, 01 Oct 2022 12:00:30 GMT", want: 30 * time.Second, wantOK: true},
+		{value: "Sat, 01 Oct 2022 11:00:00 GMT", want: 0, wantOK: true},
+		{value: "soon", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, ok := parseRetryAfter(tt.value, now)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,4 @@
+ALTER TABLE metrics ADD COLUMN IF NOT EXISTS labels JSONB NOT NULL DEFAULT '{}';
+
+ALTER TABLE metrics DROP CONSTRAINT IF EXISTS metrics_pkey;
+ALTER TABLE metrics ADD CONSTRAINT metrics_pkey PRIMARY KEY (id, type, labels);
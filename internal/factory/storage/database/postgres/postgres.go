@@ -69,7 +69,7 @@ func (p *Postgres) Migrate() {
 }
 
 func (p *Postgres) GetCollection() (map[string]metrics.Metrics, error) {
-	q := `SELECT id, type, delta, value FROM metrics;`
+	q := `SELECT id, type, labels, delta, value, histogram, summary FROM metrics;`
 	rows, err := p.pool.Query(context.Background(), q)
 	if err != nil {
 		return nil, err
@@ -79,11 +79,12 @@ func (p *Postgres) GetCollection() (map[string]metrics.Metrics, error) {
 
 	for rows.Next() {
 		var metric metrics.Metrics
-		err = rows.Scan(&metric.ID, &metric.Type, &metric.Delta, &metric.Value)
+		err = rows.Scan(&metric.ID, &metric.Type, &metric.Labels, &metric.Delta, &metric.Value, &metric.Histogram, &metric.Summary)
 		if err != nil {
 			return nil, err
 		}
-		metricsCollection[metric.ID] = metric
+		metric.WithLabels(metric.Labels)
+		metricsCollection[metric.Key()] = metric
 	}
 
 	return metricsCollection, nil
@@ -107,30 +108,35 @@ func (p *Postgres) StoreCollection(metricsCollection map[string]metrics.Metrics)
 }
 
 func (p *Postgres) Get(metric metrics.Metrics) (*metrics.Metrics, error) {
-	q := `SELECT id, type, delta, value FROM metrics WHERE id = $1 and type = $2;`
+	q := `SELECT id, type, labels, delta, value, histogram, summary FROM metrics WHERE id = $1 and type = $2 and labels = $3;`
 
 	var m metrics.Metrics
-	err := p.pool.QueryRow(context.Background(), q, metric.ID, metric.Type).Scan(&m.ID, &m.Type, &m.Delta, &m.Value)
+	err := p.pool.QueryRow(context.Background(), q, metric.ID, metric.Type, labelsOf(metric)).
+		Scan(&m.ID, &m.Type, &m.Labels, &m.Delta, &m.Value, &m.Histogram, &m.Summary)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, metrics.ErrNoValue
 	}
+	m.WithLabels(m.Labels)
 
 	return &m, err
 }
 
 func (p *Postgres) Store(metric metrics.Metrics) error {
-	q := `SELECT id, type, delta, value FROM metrics where id = $1 and type = $2;`
+	q := `SELECT id, type, delta, value, histogram, summary FROM metrics where id = $1 and type = $2 and labels = $3;`
 	var m metrics.Metrics
-	err := p.pool.QueryRow(context.Background(), q, metric.ID, metric.Type).Scan(&m.ID, &m.Type, &m.Delta, &m.Value)
+	err := p.pool.QueryRow(context.Background(), q, metric.ID, metric.Type, labelsOf(metric)).
+		Scan(&m.ID, &m.Type, &m.Delta, &m.Value, &m.Histogram, &m.Summary)
 
 	if errors.Is(err, pgx.ErrNoRows) {
-		q = `INSERT INTO metrics (id, type, delta, value) VALUES ($1, $2, $3, $4);`
+		q = `INSERT INTO metrics (id, type, labels, delta, value, histogram, summary) VALUES ($1, $2, $3, $4, $5, $6, $7);`
 	} else {
-		q = `UPDATE metrics SET delta = $3, value = $4 WHERE id = $1 and type = $2;`
+		q = `UPDATE metrics SET delta = $4, value = $5, histogram = $6, summary = $7 WHERE id = $1 and type = $2 and labels = $3;`
+	}
+	if err = metrics.Update(&m, &metric); err != nil {
+		return fmt.Errorf("metric [%s]: %w", metric.Key(), err)
 	}
-	metrics.Update(&m, &metric)
 
-	if _, err = p.pool.Exec(context.Background(), q, m.ID, m.Type, m.Delta, m.Value); err != nil {
+	if _, err = p.pool.Exec(context.Background(), q, m.ID, m.Type, labelsOf(m), m.Delta, m.Value, m.Histogram, m.Summary); err != nil {
 		return err
 	}
 
@@ -145,6 +151,14 @@ func (p *Postgres) CleanUp() error {
 	return nil
 }
 
+// labelsOf возвращает метки метрики для колонки labels, которая не может быть NULL
+func labelsOf(metric metrics.Metrics) map[string]string {
+	if metric.Labels == nil {
+		return map[string]string{}
+	}
+	return metric.Labels
+}
+
 func (p *Postgres) Ping(ctx context.Context) error {
 	return p.pool.Ping(ctx)
 }
@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Функции агрегации значений внутри одного шага
+const (
+	AggregationAvg  = "avg"
+	AggregationMin  = "min"
+	AggregationMax  = "max"
+	AggregationSum  = "sum"
+	AggregationLast = "last"
+	AggregationRate = "rate" // Скорость роста счётчика в секунду
+)
+
+var ErrUnsupportedAggregation = errors.New("unsupported aggregation")
+
+// Downsample группирует значения по шагам [from + i*step, from + (i+1)*step) и агрегирует каждый шаг.
+// Значения должны быть отсортированы по времени. Шаги без значений пропускаются
+func Downsample(samples []Sample, from time.Time, to time.Time, step time.Duration, aggregation string) ([]Sample, error) {
+	if step <= 0 {
+		return nil, errors.New("step must be positive")
+	}
+	if to.Before(from) {
+		return nil, errors.New("the end of the range is before its start")
+	}
+	switch aggregation {
+	case AggregationAvg, AggregationMin, AggregationMax, AggregationSum, AggregationLast, AggregationRate:
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAggregation, aggregation)
+	}
+
+	var points []Sample
+	var previous *Sample
+	i := 0
+	for bucketStart := from; !bucketStart.After(to); bucketStart = bucketStart.Add(step) {
+		bucketEnd := bucketStart.Add(step)
+
+		for i < len(samples) && samples[i].Timestamp.Before(bucketStart) {
+			previous = &samples[i]
+			i++
+		}
+		first := i
+		for i < len(samples) && samples[i].Timestamp.Before(bucketEnd) && !samples[i].Timestamp.After(to) {
+			i++
+		}
+		bucket := samples[first:i]
+		if len(bucket) == 0 {
+			continue
+		}
+
+		value, ok := aggregate(bucket, previous, aggregation)
+		if ok {
+			points = append(points, Sample{Timestamp: bucketStart, Value: value})
+		}
+		previous = &samples[i-1]
+	}
+
+	if points == nil {
+		points = []Sample{}
+	}
+
+	return points, nil
+}
+
+func aggregate(bucket []Sample, previous *Sample, aggregation string) (float64, bool) {
+	last := bucket[len(bucket)-1]
+
+	switch aggregation {
+	case AggregationAvg, AggregationSum:
+		var sum float64
+		for _, sample := range bucket {
+			sum += sample.Value
+		}
+		if aggregation == AggregationAvg {
+			return sum / float64(len(bucket)), true
+		}
+		return sum, true
+	case AggregationMin:
+		result := math.Inf(1)
+		for _, sample := range bucket {
+			result = math.Min(result, sample.Value)
+		}
+		return result, true
+	case AggregationMax:
+		result := math.Inf(-1)
+		for _, sample := range bucket {
+			result = math.Max(result, sample.Value)
+		}
+		return result, true
+	case AggregationLast:
+		return last.Value, true
+	case AggregationRate:
+		start := bucket[0]
+		if previous != nil {
+			start = *previous
+		}
+		elapsed := last.Timestamp.Sub(start.Timestamp).Seconds()
+		if elapsed <= 0 {
+			return 0, false
+		}
+		return counterIncrease(start, bucket) / elapsed, true
+	}
+
+	return 0, false
+}
+
+// counterIncrease считает прирост счётчика с учётом его сбросов
+func counterIncrease(start Sample, bucket []Sample) float64 {
+	var increase float64
+	prev := start.Value
+	for _, sample := range bucket {
+		if sample.Value < prev {
+			increase += sample.Value
+		} else {
+			increase += sample.Value - prev
+		}
+		prev = sample.Value
+	}
+
+	return increase
+}
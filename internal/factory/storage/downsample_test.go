@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownsample(t *testing.T) {
+	start := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	at := func(seconds int) time.Time {
+		return start.Add(time.Duration(seconds) * time.Second)
+	}
+	samples := []Sample{
+		{Timestamp: at(0), Value: 10},
+		{Timestamp: at(20), Value: 30},
+		{Timestamp: at(40), Value: 20},
+		{Timestamp: at(60), Value: 50},
+		{Timestamp: at(80), Value: 10},
+		{Timestamp: at(150), Value: 40},
+	}
+
+	tests := []struct {
+		name        string
+		aggregation string
+		want        []Sample
+	}{
+		{
+			name:        "avg",
+			aggregation: AggregationAvg,
+			want:        []Sample{{Timestamp: at(0), Value: 20}, {Timestamp: at(60), Value: 30}, {Timestamp: at(120), Value: 40}},
+		},
+		{
+			name:        "min",
+			aggregation: AggregationMin,
+			want:        []Sample{{Timestamp: at(0), Value: 10}, {Timestamp: at(60), Value: 10}, {Timestamp: at(120), Value: 40}},
+		},
+		{
+			name:        "max",
+			aggregation: AggregationMax,
+			want:        []Sample{{Timestamp: at(0), Value: 30}, {Timestamp: at(60), Value: 50}, {Timestamp: at(120), Value: 40}},
+		},
+		{
+			name:        "sum",
+			aggregation: AggregationSum,
+			want:        []Sample{{Timestamp: at(0), Value: 60}, {Timestamp: at(60), Value: 60}, {Timestamp: at(120), Value: 40}},
+		},
+		{
+			name:        "last",
+			aggregation: AggregationLast,
+			want:        []Sample{{Timestamp: at(0), Value: 20}, {Timestamp: at(60), Value: 10}, {Timestamp: at(120), Value: 40}},
+		},
+		{
+			name:        "rate with counter reset",
+			aggregation: AggregationRate,
+			want:        []Sample{{Timestamp: at(0), Value: 1}, {Timestamp: at(60), Value: 1}, {Timestamp: at(120), Value: 30.0 / 70}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Downsample(samples, start, at(179), time.Minute, tt.aggregation)
+			require.NoError(t, err)
+			require.Len(t, got, len(tt.want))
+			for i := range tt.want {
+				assert.Equal(t, tt.want[i].Timestamp, got[i].Timestamp)
+				assert.InDelta(t, tt.want[i].Value, got[i].Value, 1e-9)
+			}
+		})
+	}
+}
+
+func TestDownsample_Errors(t *testing.T) {
+	now := time.Now()
+
+	_, err := Downsample(nil, now, now.Add(time.Hour), time.Minute, "median")
+	assert.ErrorIs(t, err, ErrUnsupportedAggregation)
+
+	_, err = Downsample(nil, now, now.Add(time.Hour), 0, AggregationAvg)
+	assert.Error(t, err)
+
+	_, err = Downsample(nil, now, now.Add(-time.Hour), time.Minute, AggregationAvg)
+	assert.Error(t, err)
+
+	got, err := Downsample(nil, now, now.Add(time.Hour), time.Minute, AggregationAvg)
+	require.NoError(t, err)
+	assert.Empty(t, got)
+}
@@ -0,0 +1,208 @@
+// Package history хранит историю значений метрик поверх любого другого хранилища.
+package history
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/factory/storage"
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/metrics"
+)
+
+// maxPruneInterval наибольший интервал удаления устаревших значений из истории
+const maxPruneInterval = time.Minute
+
+// HistoryStorage сохраняет последние значения во вложенное хранилище,
+// а каждое обновление метрики дописывает в её историю с отметкой времени
+type HistoryStorage struct {
+	storage   storage.Storager
+	retention time.Duration
+	now       func() time.Time
+
+	// mu защищает историю и упорядочивает запись во вложенное хранилище,
+	// чтобы накопленное значение счётчика попадало в историю ровно один раз
+	mu      sync.RWMutex
+	samples map[string][]storage.Sample
+
+	stop      chan struct{}
+	closeOnce sync.Once
+}
+
+var _ storage.Storager = (*HistoryStorage)(nil)
+var _ storage.RangeGetter = (*HistoryStorage)(nil)
+var _ storage.CollectionRestorer = (*HistoryStorage)(nil)
+
+// NewHistoryStorage оборачивает хранилище. Значения старше retention периодически удаляются из истории
+// до вызова Close
+func NewHistoryStorage(base storage.Storager, retention time.Duration) *HistoryStorage {
+	hs := &HistoryStorage{
+		storage:   base,
+		retention: retention,
+		now:       time.Now,
+		mu:        sync.RWMutex{},
+		samples:   make(map[string][]storage.Sample),
+		stop:      make(chan struct{}),
+	}
+
+	if retention > 0 {
+		interval := retention
+		if interval > maxPruneInterval {
+			interval = maxPruneInterval
+		}
+		go hs.runPruning(interval)
+	}
+
+	return hs
+}
+
+func (hs *HistoryStorage) GetCollection() (map[string]metrics.Metrics, error) {
+	return hs.storage.GetCollection()
+}
+
+func (hs *HistoryStorage) StoreCollection(collection map[string]metrics.Metrics) error {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	if err := hs.storage.StoreCollection(collection); err != nil {
+		return err
+	}
+
+	for _, metric := range collection {
+		hs.record(metric)
+	}
+
+	return nil
+}
+
+// RestoreCollection сохраняет восстановленные из резервной копии значения, не дописывая их в историю
+func (hs *HistoryStorage) RestoreCollection(collection map[string]metrics.Metrics) error {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	return hs.storage.StoreCollection(collection)
+}
+
+func (hs *HistoryStorage) Get(metric metrics.Metrics) (*metrics.Metrics, error) {
+	return hs.storage.Get(metric)
+}
+
+func (hs *HistoryStorage) Store(metric metrics.Metrics) error {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	if err := hs.storage.Store(metric); err != nil {
+		return err
+	}
+
+	hs.record(metric)
+
+	return nil
+}
+
+// GetRange возвращает значения метрики за промежуток [from, to] в порядке возрастания времени
+func (hs *HistoryStorage) GetRange(metric metrics.Metrics, from time.Time, to time.Time) ([]storage.Sample, error) {
+	if valid, err := metric.Validate(); !valid {
+		return nil, err
+	}
+
+	hs.mu.RLock()
+	defer hs.mu.RUnlock()
+
+	samples, ok := hs.samples[metric.Key()]
+	if !ok {
+		return nil, metrics.ErrNoValue
+	}
+
+	start := sort.Search(len(samples), func(i int) bool {
+		return !samples[i].Timestamp.Before(from)
+	})
+	end := sort.Search(len(samples), func(i int) bool {
+		return samples[i].Timestamp.After(to)
+	})
+	if start >= end {
+		return []storage.Sample{}, nil
+	}
+
+	result := make([]storage.Sample, end-start)
+	copy(result, samples[start:end])
+
+	return result, nil
+}
+
+// CleanUp очищает только последние значения, история удаляется по истечении retention
+func (hs *HistoryStorage) CleanUp() error {
+	return hs.storage.CleanUp()
+}
+
+func (hs *HistoryStorage) Ping(ctx context.Context) error {
+	return hs.storage.Ping(ctx)
+}
+
+// Close останавливает удаление устаревших значений и закрывает вложенное хранилище
+func (hs *HistoryStorage) Close() {
+	hs.closeOnce.Do(func() {
+		close(hs.stop)
+	})
+	hs.storage.Close()
+}
+
+// record дописывает текущее значение метрики в историю. Для счётчика берётся накопленное значение из хранилища.
+// Вызывается под hs.mu сразу после записи во вложенное хранилище
+func (hs *HistoryStorage) record(metric metrics.Metrics) {
+	if stored, err := hs.storage.Get(metric); err == nil {
+		metric = *stored
+	}
+
+	value, err := metric.Float()
+	if err != nil {
+		return
+	}
+
+	now := hs.now()
+	key := metric.Key()
+	hs.samples[key] = hs.expire(append(hs.samples[key], storage.Sample{Timestamp: now, Value: value}), now)
+}
+
+func (hs *HistoryStorage) runPruning(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-hs.stop:
+			return
+		case <-ticker.C:
+			hs.prune()
+		}
+	}
+}
+
+// prune удаляет устаревшие значения из истории всех метрик, в том числе тех, что перестали обновляться
+func (hs *HistoryStorage) prune() {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	now := hs.now()
+	for key, samples := range hs.samples {
+		samples = hs.expire(samples, now)
+		if len(samples) == 0 {
+			delete(hs.samples, key)
+			continue
+		}
+		hs.samples[key] = samples
+	}
+}
+
+// expire отбрасывает значения старше retention, samples упорядочены по времени
+func (hs *HistoryStorage) expire(samples []storage.Sample, now time.Time) []storage.Sample {
+	if hs.retention <= 0 {
+		return samples
+	}
+
+	expired := sort.Search(len(samples), func(i int) bool {
+		return samples[i].Timestamp.After(now.Add(-hs.retention))
+	})
+	return samples[expired:]
+}
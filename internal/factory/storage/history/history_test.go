@@ -0,0 +1,144 @@
+package history
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/factory/storage"
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/factory/storage/memory"
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/metrics"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Add(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func TestHistoryStorage_GetRange(t *testing.T) {
+	start := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	clock := &fakeClock{now: start}
+
+	hs := NewHistoryStorage(memory.NewMemoryStorage(), 0)
+	hs.now = clock.Now
+
+	for _, value := range []float64{1, 2, 3, 4} {
+		require.NoError(t, hs.Store(*metrics.NewGauge("Alloc", value)))
+		require.NoError(t, hs.Store(*metrics.NewCounter("PollCount", 1)))
+		clock.Add(10 * time.Second)
+	}
+
+	samples, err := hs.GetRange(*metrics.New(metrics.StringGaugeType, "Alloc"), start.Add(10*time.Second), start.Add(20*time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, []storage.Sample{
+		{Timestamp: start.Add(10 * time.Second), Value: 2},
+		{Timestamp: start.Add(20 * time.Second), Value: 3},
+	}, samples)
+
+	samples, err = hs.GetRange(*metrics.New(metrics.StringCounterType, "PollCount"), start, start.Add(time.Minute))
+	require.NoError(t, err)
+	require.Len(t, samples, 4)
+	assert.Equal(t, float64(4), samples[3].Value, "counter samples are cumulative")
+
+	samples, err = hs.GetRange(*metrics.New(metrics.StringGaugeType, "Alloc"), start.Add(time.Hour), start.Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, samples)
+
+	_, err = hs.GetRange(*metrics.New(metrics.StringGaugeType, "HeapAlloc"), start, start.Add(time.Minute))
+	assert.ErrorIs(t, err, metrics.ErrNoValue)
+
+	latest, err := hs.Get(*metrics.New(metrics.StringGaugeType, "Alloc"))
+	require.NoError(t, err)
+	assert.Equal(t, float64(4), *latest.Value)
+}
+
+func TestHistoryStorage_Retention(t *testing.T) {
+	start := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	clock := &fakeClock{now: start}
+
+	hs := NewHistoryStorage(memory.NewMemoryStorage(), 30*time.Second)
+	hs.now = clock.Now
+
+	for _, value := range []float64{1, 2, 3, 4, 5} {
+		require.NoError(t, hs.Store(*metrics.NewGauge("Alloc", value)))
+		clock.Add(10 * time.Second)
+	}
+
+	samples, err := hs.GetRange(*metrics.New(metrics.StringGaugeType, "Alloc"), start, clock.Now())
+	require.NoError(t, err)
+	require.Len(t, samples, 3)
+	assert.Equal(t, float64(3), samples[0].Value)
+
+	require.NoError(t, hs.CleanUp())
+	samples, err = hs.GetRange(*metrics.New(metrics.StringGaugeType, "Alloc"), start, clock.Now())
+	require.NoError(t, err)
+	assert.Len(t, samples, 3, "clean up keeps the history")
+}
+
+func TestHistoryStorage_Prune(t *testing.T) {
+	start := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	clock := &fakeClock{now: start}
+
+	hs := NewHistoryStorage(memory.NewMemoryStorage(), 30*time.Second)
+	defer hs.Close()
+	hs.now = clock.Now
+
+	require.NoError(t, hs.Store(*metrics.NewGauge("Stale", 1)))
+	clock.Add(20 * time.Second)
+	require.NoError(t, hs.Store(*metrics.NewGauge("Alloc", 2)))
+	clock.Add(20 * time.Second)
+
+	hs.prune()
+
+	_, err := hs.GetRange(*metrics.New(metrics.StringGaugeType, "Stale"), start, clock.Now())
+	assert.ErrorIs(t, err, metrics.ErrNoValue, "a series that is no longer written is pruned too")
+	samples, err := hs.GetRange(*metrics.New(metrics.StringGaugeType, "Alloc"), start, clock.Now())
+	require.NoError(t, err)
+	assert.Len(t, samples, 1)
+}
+
+func TestHistoryStorage_RestoreCollection(t *testing.T) {
+	hs := NewHistoryStorage(memory.NewMemoryStorage(), 0)
+	backup := memory.NewMemoryStorage()
+	require.NoError(t, backup.Store(*metrics.NewCounter("PollCount", 5)))
+
+	storage.NewRestorer(backup, hs).Run(true, time.Hour)
+
+	restored, err := hs.Get(*metrics.New(metrics.StringCounterType, "PollCount"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), *restored.Delta)
+	_, err = hs.GetRange(*metrics.New(metrics.StringCounterType, "PollCount"), time.Time{}, time.Now())
+	assert.ErrorIs(t, err, metrics.ErrNoValue, "restored values are not updates")
+}
+
+func TestHistoryStorage_ConcurrentCounter(t *testing.T) {
+	hs := NewHistoryStorage(memory.NewMemoryStorage(), 0)
+
+	const updates = 100
+	var wg sync.WaitGroup
+	for i := 0; i < updates; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, hs.Store(*metrics.NewCounter("PollCount", 1)))
+		}()
+	}
+	wg.Wait()
+
+	samples, err := hs.GetRange(*metrics.New(metrics.StringCounterType, "PollCount"), time.Time{}, time.Now())
+	require.NoError(t, err)
+	require.Len(t, samples, updates)
+	for i, sample := range samples {
+		assert.Equal(t, float64(i+1), sample.Value, "each cumulative value is recorded exactly once")
+	}
+}
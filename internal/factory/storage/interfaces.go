@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"time"
 
 	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/metrics"
 )
@@ -23,6 +24,12 @@ type CollectionStorer interface {
 	StoreCollection(map[string]metrics.Metrics) error
 }
 
+// CollectionRestorer сохраняет значения, восстановленные из резервной копии. В отличие от StoreCollection
+// восстановление не считается обновлением метрик
+type CollectionRestorer interface {
+	RestoreCollection(map[string]metrics.Metrics) error
+}
+
 type Getter interface {
 	Get(metric metrics.Metrics) (*metrics.Metrics, error)
 }
@@ -42,3 +49,30 @@ type Pinger interface {
 type Closer interface {
 	Close()
 }
+
+// Swapper позволяет атомарно забрать все накопленные метрики, оставив хранилище пустым
+type Swapper interface {
+	Swap() (map[string]metrics.Metrics, error)
+}
+
+// Merger возвращает ранее забранные метрики в хранилище. Забранные значения считаются более старыми:
+// счётчики, гистограммы и сводки складываются с накопленными после, датчики остаются более новыми
+type Merger interface {
+	Merge(collection map[string]metrics.Metrics) error
+}
+
+// UpdateTracker запоминает время обновления метрик, полученных по любому протоколу
+type UpdateTracker interface {
+	Touch(now time.Time, ids ...string)
+}
+
+// Sample значение метрики в момент времени. Для счётчика это накопленное значение
+type Sample struct {
+	Timestamp time.Time `json:"timestamp"`
+	Value     float64   `json:"value"`
+}
+
+// RangeGetter позволяет получить историю значений метрики за промежуток [from, to]
+type RangeGetter interface {
+	GetRange(metric metrics.Metrics, from time.Time, to time.Time) ([]Sample, error)
+}
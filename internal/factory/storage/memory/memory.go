@@ -24,7 +24,12 @@ func (ms *MemoryStorage) GetCollection() (map[string]metrics.Metrics, error) {
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 
-	return ms.metrics, nil
+	collection := make(map[string]metrics.Metrics, len(ms.metrics))
+	for key, metric := range ms.metrics {
+		collection[key] = metric
+	}
+
+	return collection, nil
 }
 
 func (ms *MemoryStorage) StoreCollection(collection map[string]metrics.Metrics) error {
@@ -45,7 +50,7 @@ func (ms *MemoryStorage) Get(metric metrics.Metrics) (*metrics.Metrics, error) {
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 
-	if value, ok := ms.metrics[metric.ID]; ok {
+	if value, ok := ms.metrics[metric.Key()]; ok {
 		return &value, nil
 	}
 
@@ -60,19 +65,54 @@ func (ms *MemoryStorage) Store(metric metrics.Metrics) error {
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 
-	storedMetric, ok := ms.metrics[metric.ID]
+	key := metric.Key()
+	storedMetric, ok := ms.metrics[key]
 	if !ok {
-		ms.metrics[metric.ID] = *metrics.New(metric.Type, metric.ID)
-		storedMetric = ms.metrics[metric.ID]
+		storedMetric = *metrics.New(metric.Type, metric.ID)
 	}
 
-	metrics.Update(&storedMetric, &metric)
+	if err := metrics.Update(&storedMetric, &metric); err != nil {
+		return fmt.Errorf("metric [%s]: %w", key, err)
+	}
 
-	ms.metrics[metric.ID] = storedMetric
+	ms.metrics[key] = storedMetric
 
 	return nil
 }
 
+// Swap забирает все метрики и подменяет коллекцию пустой
+func (ms *MemoryStorage) Swap() (map[string]metrics.Metrics, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	collection := ms.metrics
+	ms.metrics = make(map[string]metrics.Metrics)
+
+	return collection, nil
+}
+
+// Merge возвращает забранные через Swap метрики, применяя поверх них накопленные с тех пор значения.
+// Если забранную метрику нельзя объединить с накопленной, то остаётся накопленная
+func (ms *MemoryStorage) Merge(collection map[string]metrics.Metrics) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	var mergeErr error
+	for key, metric := range collection {
+		if current, ok := ms.metrics[key]; ok {
+			if err := metrics.Update(&metric, &current); err != nil {
+				if mergeErr == nil {
+					mergeErr = fmt.Errorf("metric [%s]: %w", key, err)
+				}
+				continue
+			}
+		}
+		ms.metrics[key] = metric
+	}
+
+	return mergeErr
+}
+
 func (ms *MemoryStorage) CleanUp() error {
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
@@ -0,0 +1,137 @@
+package memory
+
+import (
+	"math"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/metrics"
+)
+
+func TestMemoryStorage_SwapAndMerge(t *testing.T) {
+	ms := NewMemoryStorage()
+	require.NoError(t, ms.Store(*metrics.NewCounter("PollCount", 3)))
+	require.NoError(t, ms.Store(*metrics.NewGauge("Alloc", 100)))
+	require.NoError(t, ms.Store(*metrics.NewGauge("Frees", 7)))
+
+	snapshot, err := ms.Swap()
+	require.NoError(t, err)
+	assert.Len(t, snapshot, 3)
+
+	collection, err := ms.GetCollection()
+	require.NoError(t, err)
+	assert.Empty(t, collection, "storage must be empty right after the swap")
+
+	// пока пачка отправлялась, сбор метрик продолжался
+	require.NoError(t, ms.Store(*metrics.NewCounter("PollCount", 2)))
+	require.NoError(t, ms.Store(*metrics.NewGauge("Alloc", 200)))
+
+	require.NoError(t, ms.Merge(snapshot))
+
+	pollCount, err := ms.Get(*metrics.New(metrics.StringCounterType, "PollCount"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), *pollCount.Delta, "counters must be summed")
+
+	alloc, err := ms.Get(*metrics.New(metrics.StringGaugeType, "Alloc"))
+	require.NoError(t, err)
+	assert.Equal(t, float64(200), *alloc.Value, "a newer gauge must win")
+
+	frees, err := ms.Get(*metrics.New(metrics.StringGaugeType, "Frees"))
+	require.NoError(t, err)
+	assert.Equal(t, float64(7), *frees.Value, "a gauge absent since the swap must be restored")
+}
+
+func TestMemoryStorage_ConcurrentHistogram(t *testing.T) {
+	ms := NewMemoryStorage()
+	bounds := []float64{1, 10}
+	observed := metrics.NewHistogram("Latency", bounds)
+	observed.Histogram.Observe(5)
+	require.NoError(t, ms.Store(*observed))
+
+	snapshot, err := ms.GetCollection()
+	require.NoError(t, err)
+	require.NoError(t, ms.Store(*observed))
+	assert.Equal(t, uint64(1), snapshot["Latency"].Histogram.Count, "values given to readers do not change")
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			collection, err := ms.GetCollection()
+			assert.NoError(t, err)
+			metric := collection["Latency"]
+			assert.Equal(t, metric.Histogram.Count, metric.Histogram.Cumulative()[len(bounds)])
+			runtime.Gosched()
+		}
+	}()
+
+	for i := 0; i < 100; i++ {
+		histogram := metrics.NewHistogram("Latency", bounds)
+		histogram.Histogram.Observe(float64(i % 20))
+		require.NoError(t, ms.Store(*histogram))
+		runtime.Gosched()
+	}
+	close(done)
+	wg.Wait()
+
+	metric, err := ms.Get(*metrics.New(metrics.StringHistogramType, "Latency"))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(102), metric.Histogram.Count)
+}
+
+func TestMemoryStorage_ConcurrentSummary(t *testing.T) {
+	ms := NewMemoryStorage()
+	observed, err := metrics.NewSummary("Latency", 0.01)
+	require.NoError(t, err)
+	observed.Summary.Add(5)
+	require.NoError(t, ms.Store(*observed))
+
+	snapshot, err := ms.GetCollection()
+	require.NoError(t, err)
+	require.NoError(t, ms.Store(*observed))
+	assert.Equal(t, uint64(1), snapshot["Latency"].Summary.Count, "values given to readers do not change")
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			collection, err := ms.GetCollection()
+			assert.NoError(t, err)
+			metric := collection["Latency"]
+			assert.False(t, math.IsNaN(metric.Summary.Quantile(0.99)))
+			runtime.Gosched()
+		}
+	}()
+
+	for i := 0; i < 100; i++ {
+		summary, err := metrics.NewSummary("Latency", 0.01)
+		require.NoError(t, err)
+		summary.Summary.Add(float64(i))
+		require.NoError(t, ms.Store(*summary))
+		runtime.Gosched()
+	}
+	close(done)
+	wg.Wait()
+
+	metric, err := ms.Get(*metrics.New(metrics.StringSummaryType, "Latency"))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(102), metric.Summary.Count)
+}
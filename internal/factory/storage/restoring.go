@@ -66,11 +66,10 @@ func (r *Restorer) restore() error {
 		return err
 	}
 
-	if err = r.storage.StoreCollection(collection); err != nil {
-		return err
+	if restorer, ok := r.storage.(CollectionRestorer); ok {
+		return restorer.RestoreCollection(collection)
 	}
-
-	return nil
+	return r.storage.StoreCollection(collection)
 }
 
 func (r *Restorer) runStoring(interval time.Duration) error {
@@ -1,23 +1,35 @@
 package factory
 
 import (
+	"time"
+
 	"github.com/rs/zerolog/log"
 
 	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/factory/storage"
 	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/factory/storage/database/postgres"
 	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/factory/storage/file"
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/factory/storage/history"
 	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/factory/storage/memory"
 )
 
 // StorageFactory реализация абстрактной фабрики для хранилища
 type StorageFactory struct {
-	DSN      string // Если передана строка для подключения к БД, то будет БД-хранилище
-	FilePath string // Если передан путь до файла, то будет файловое хранилище
-	Testing  bool   // (Временное решение) Нужно выставить в true для тестов
+	DSN              string        // Если передана строка для подключения к БД, то будет БД-хранилище
+	FilePath         string        // Если передан путь до файла, то будет файловое хранилище
+	HistoryRetention time.Duration // Если передан срок хранения, то хранилище будет дополнительно хранить историю значений
+	Testing          bool          // (Временное решение) Нужно выставить в true для тестов
 }
 
 // CreateStorage возвращает новый экземпляр хранилища
 func (sf *StorageFactory) CreateStorage() storage.Storager {
+	base := sf.createBaseStorage()
+	if sf.HistoryRetention > 0 {
+		return history.NewHistoryStorage(base, sf.HistoryRetention)
+	}
+	return base
+}
+
+func (sf *StorageFactory) createBaseStorage() storage.Storager {
 	if sf.DSN != "" {
 		if sf.Testing {
 			return &postgres.Postgres{}
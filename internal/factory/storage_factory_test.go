@@ -2,19 +2,22 @@ package factory
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
 	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/factory/storage"
 	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/factory/storage/database/postgres"
 	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/factory/storage/file"
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/factory/storage/history"
 	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/factory/storage/memory"
 )
 
 func TestStorageFactory_CreateStorage(t *testing.T) {
 	type fields struct {
-		DSN      string
-		FilePath string
+		DSN              string
+		FilePath         string
+		HistoryRetention time.Duration
 	}
 	tests := []struct {
 		name   string
@@ -48,13 +51,21 @@ func TestStorageFactory_CreateStorage(t *testing.T) {
 			},
 			want: &postgres.Postgres{},
 		},
+		{
+			name: "history storage",
+			fields: fields{
+				HistoryRetention: time.Hour,
+			},
+			want: &history.HistoryStorage{},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			sf := &StorageFactory{
-				DSN:      tt.fields.DSN,
-				FilePath: tt.fields.FilePath,
-				Testing:  true,
+				DSN:              tt.fields.DSN,
+				FilePath:         tt.fields.FilePath,
+				HistoryRetention: tt.fields.HistoryRetention,
+				Testing:          true,
 			}
 			if got := sf.CreateStorage(); !assert.IsTypef(t, tt.want, got, "") {
 				t.Errorf("CreateStorage() = %v, want %v", got, tt.want)
@@ -0,0 +1,57 @@
+package graphite
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/factory/storage"
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/linelistener"
+)
+
+// maxLineLength максимальная длина строки протокола
+const maxLineLength = 64 * 1024
+
+// Handler сохраняет строки протокола Graphite в хранилище
+type Handler struct {
+	store   storage.Storer
+	tracker storage.UpdateTracker
+}
+
+func NewHandler(store storage.Storer) *Handler {
+	return &Handler{
+		store: store,
+	}
+}
+
+// SetUpdateTracker позволяет отслеживать время последнего обновления каждой метрики
+func (h *Handler) SetUpdateTracker(tracker storage.UpdateTracker) {
+	h.tracker = tracker
+}
+
+// Handle разбирает и сохраняет одну строку
+func (h *Handler) Handle(line string) error {
+	metric, err := ParseLine(line)
+	if err != nil {
+		return err
+	}
+	if err = h.store.Store(*metric); err != nil {
+		return err
+	}
+	if h.tracker != nil {
+		h.tracker.Touch(time.Now(), metric.Key())
+	}
+	return nil
+}
+
+// ServeTCP принимает соединения из listener и читает из каждого строки, пока не завершится контекст.
+// При завершении контекста открытые соединения закрываются
+func (h *Handler) ServeTCP(ctx context.Context, listener net.Listener) error {
+	return linelistener.Serve(ctx, listener, maxLineLength, func(line []byte, from net.Addr) {
+		if err := h.Handle(string(line)); err != nil {
+			log.Warn().Err(err).Str("from", from.String()).Msg("Parsing Graphite line")
+		}
+	})
+}
@@ -0,0 +1,70 @@
+package graphite
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/factory/storage/memory"
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/metrics"
+)
+
+type touchedIDs []string
+
+func (t *touchedIDs) Touch(_ time.Time, ids ...string) {
+	*t = append(*t, ids...)
+}
+
+func TestHandler_Handle(t *testing.T) {
+	store := memory.NewMemoryStorage()
+	h := NewHandler(store)
+	var touched touchedIDs
+	h.SetUpdateTracker(&touched)
+
+	require.NoError(t, h.Handle("jobs.backup.duration;host=db1 42 1700000000"))
+	assert.Error(t, h.Handle("jobs.backup.duration"))
+
+	collection, err := store.GetCollection()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]metrics.Metrics{
+		`jobs.backup.duration{host="db1"}`: *metrics.NewGauge("jobs.backup.duration", 42).WithLabels(map[string]string{"host": "db1"}),
+	}, collection)
+	assert.Equal(t, touchedIDs{`jobs.backup.duration{host="db1"}`}, touched)
+}
+
+func TestHandler_ServeTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	store := memory.NewMemoryStorage()
+	h := NewHandler(store)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- h.ServeTCP(ctx, listener)
+	}()
+
+	client, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	defer client.Close()
+	_, err = client.Write([]byte("queue.depth 3 1700000000\r\nbroken\n\nqueue.depth 5 1700000010\nbackup.ok 1 1700000010\n"))
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		collection, err := store.GetCollection()
+		return err == nil && len(collection) == 2
+	}, time.Second, 10*time.Millisecond)
+	collection, err := store.GetCollection()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]metrics.Metrics{
+		"queue.depth": *metrics.NewGauge("queue.depth", 5),
+		"backup.ok":   *metrics.NewGauge("backup.ok", 1),
+	}, collection)
+
+	cancel()
+	assert.NoError(t, <-done, "open connections are closed on shutdown")
+}
@@ -0,0 +1,61 @@
+// Package graphite принимает метрики в текстовом протоколе Graphite и сохраняет их в хранилище как датчики.
+package graphite
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/metrics"
+)
+
+// ParseLine разбирает строку вида path[;tag=value...] value [timestamp] в датчик с путём в качестве названия
+// и тегами в качестве меток. Метка времени проверяется, но не используется: значение считается текущим
+func ParseLine(line string) (*metrics.Metrics, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 || len(fields) > 3 {
+		return nil, fmt.Errorf("expected \"path value timestamp\", got %q", line)
+	}
+
+	path, labels, err := parsePath(fields[0])
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		return nil, fmt.Errorf("metric %q has an invalid value %q", path, fields[1])
+	}
+
+	if len(fields) == 3 {
+		if _, err = strconv.ParseFloat(fields[2], 64); err != nil {
+			return nil, fmt.Errorf("metric %q has an invalid timestamp %q", path, fields[2])
+		}
+	}
+
+	return metrics.NewGauge(path, value).WithLabels(labels), nil
+}
+
+// parsePath отделяет теги в синтаксисе Graphite: my.series;tag1=value1;tag2=value2
+func parsePath(s string) (string, map[string]string, error) {
+	parts := strings.Split(s, ";")
+	path := parts[0]
+	if path == "" {
+		return "", nil, errors.New("empty metric path")
+	}
+	if len(parts) == 1 {
+		return path, nil, nil
+	}
+
+	labels := make(map[string]string, len(parts)-1)
+	for _, tag := range parts[1:] {
+		name, value, found := strings.Cut(tag, "=")
+		if !found || name == "" || value == "" {
+			return "", nil, fmt.Errorf("metric %q has an invalid tag %q, expected name=value", path, tag)
+		}
+		labels[name] = value
+	}
+	return path, labels, nil
+}
@@ -0,0 +1,52 @@
+package graphite
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/metrics"
+)
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		want    *metrics.Metrics
+		wantErr bool
+	}{
+		{
+			name: "Path value timestamp",
+			line: "servers.web1.cpu.load 0.75 1700000000",
+			want: metrics.NewGauge("servers.web1.cpu.load", 0.75),
+		},
+		{
+			name: "Without timestamp",
+			line: "backup.duration 42",
+			want: metrics.NewGauge("backup.duration", 42),
+		},
+		{
+			name: "Tags",
+			line: "cpu.load;host=web1;dc=eu 1.5 1700000000",
+			want: metrics.NewGauge("cpu.load", 1.5).WithLabels(map[string]string{"host": "web1", "dc": "eu"}),
+		},
+		{name: "No value", line: "cpu.load", wantErr: true},
+		{name: "Too many fields", line: "cpu.load 1 1700000000 x", wantErr: true},
+		{name: "Invalid value", line: "cpu.load high 1700000000", wantErr: true},
+		{name: "NaN value", line: "cpu.load nan 1700000000", wantErr: true},
+		{name: "Invalid timestamp", line: "cpu.load 1 yesterday", wantErr: true},
+		{name: "Empty path", line: ";host=web1 1 1700000000", wantErr: true},
+		{name: "Invalid tag", line: "cpu.load;host 1 1700000000", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metric, err := ParseLine(tt.line)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, metric)
+		})
+	}
+}
@@ -0,0 +1,35 @@
+// Package httpjson читает тела запросов и пишет ответы в JSON формате. Используется и сервером,
+// и агентом, поэтому не зависит от их пакетов.
+package httpjson
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Obj тело ответа в виде JSON-объекта
+type Obj map[string]any
+
+// Write отвечает кодом code и объектом obj в JSON формате
+func Write(rw http.ResponseWriter, code int, obj any) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(code)
+	jsonBytes, errMarshal := json.Marshal(obj)
+	if errMarshal != nil {
+		log.Error().Err(errMarshal).Msg("JSON marshling")
+	}
+	if _, err := rw.Write(jsonBytes); err != nil {
+		log.Error().Err(err).Msg("Writing response")
+	}
+}
+
+// Parse разбирает JSON из тела запроса в obj
+func Parse(r *http.Request, obj any) error {
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(obj); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+// Package linelistener принимает TCP-соединения с построчными протоколами, такими как StatsD и Graphite,
+// и передаёт обработчику каждую полученную строку.
+package linelistener
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	minBackoff = 5 * time.Millisecond
+	maxBackoff = time.Second
+)
+
+// Backoff экспоненциальная задержка после ошибок приёма, например, при нехватке файловых дескрипторов.
+// Как и net/http.Server, приём не прекращается, а повторяется после задержки от 5 мс до 1 с
+type Backoff struct {
+	delay time.Duration
+}
+
+// Wait ждёт следующую задержку и возвращает false, если контекст завершился раньше
+func (b *Backoff) Wait(ctx context.Context) bool {
+	b.delay *= 2
+	if b.delay == 0 {
+		b.delay = minBackoff
+	}
+	if b.delay > maxBackoff {
+		b.delay = maxBackoff
+	}
+
+	timer := time.NewTimer(b.delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Reset сбрасывает задержку после успешного приёма
+func (b *Backoff) Reset() {
+	b.delay = 0
+}
+
+// Handler обрабатывает непустую строку без перевода строки, полученную от from.
+// Срез line переиспользуется для следующих строк, поэтому его нельзя сохранять
+type Handler func(line []byte, from net.Addr)
+
+// Serve принимает соединения из listener и читает из каждого строки длиной до maxLineLength,
+// пока не завершится контекст или не закроется listener. Остальные ошибки приёма не останавливают
+// Serve. При завершении контекста открытые соединения закрываются
+func Serve(ctx context.Context, listener net.Listener, maxLineLength int, handle Handler) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	var backoff Backoff
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			log.Warn().Err(err).Msg("Accepting TCP connection")
+			if !backoff.Wait(ctx) {
+				return nil
+			}
+			continue
+		}
+		backoff.Reset()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			serveConn(ctx, conn, maxLineLength, handle)
+		}()
+	}
+}
+
+func serveConn(ctx context.Context, conn net.Conn, maxLineLength int, handle Handler) {
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-done:
+		}
+		conn.Close()
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 4096), maxLineLength)
+	for scanner.Scan() {
+		if line := scanner.Bytes(); len(line) > 0 {
+			handle(line, conn.RemoteAddr())
+		}
+	}
+	if err := scanner.Err(); err != nil && ctx.Err() == nil && !errors.Is(err, net.ErrClosed) {
+		log.Warn().Err(err).Str("from", conn.RemoteAddr().String()).Msg("Reading TCP connection")
+	}
+}
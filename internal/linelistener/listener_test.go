@@ -0,0 +1,122 @@
+package linelistener
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServe(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	var lines []string
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- Serve(ctx, listener, 8*1024, func(line []byte, from net.Addr) {
+			mu.Lock()
+			defer mu.Unlock()
+			lines = append(lines, string(line))
+		})
+	}()
+	received := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), lines...)
+	}
+
+	client, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	defer client.Close()
+	_, err = client.Write([]byte("first\r\n\nsecond\n"))
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return len(received()) == 2
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"first", "second"}, received(), "empty lines are skipped")
+
+	tooLong, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	defer tooLong.Close()
+	_, err = tooLong.Write([]byte(strings.Repeat("x", 16*1024) + "\n"))
+	require.NoError(t, err)
+	_, err = client.Write([]byte("third\n"))
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return len(received()) == 3
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"first", "second", "third"}, received(), "a too long line drops only its connection")
+
+	cancel()
+	assert.NoError(t, <-done, "open connections are closed on shutdown")
+}
+
+// flakyListener возвращает ошибки приёма, пока не исчерпает failures
+type flakyListener struct {
+	net.Listener
+	failures int
+}
+
+func (l *flakyListener) Accept() (net.Conn, error) {
+	if l.failures > 0 {
+		l.failures--
+		return nil, errors.New("accept: too many open files")
+	}
+	return l.Listener.Accept()
+}
+
+func TestServe_KeepsAcceptingAfterErrors(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	lines := make(chan string, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- Serve(ctx, &flakyListener{Listener: listener, failures: 3}, 1024, func(line []byte, _ net.Addr) {
+			lines <- string(line)
+		})
+	}()
+
+	client, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	defer client.Close()
+	_, err = client.Write([]byte("after errors\n"))
+	require.NoError(t, err)
+	select {
+	case line := <-lines:
+		assert.Equal(t, "after errors", line)
+	case <-time.After(time.Second):
+		t.Fatal("connection was not accepted after temporary errors")
+	}
+
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestBackoff(t *testing.T) {
+	var backoff Backoff
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		require.True(t, backoff.Wait(ctx))
+	}
+	assert.Equal(t, 4*minBackoff, backoff.delay)
+
+	backoff.delay = maxBackoff
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.False(t, backoff.Wait(cancelled))
+	assert.Equal(t, maxBackoff, backoff.delay, "the delay is capped")
+
+	backoff.Reset()
+	assert.Equal(t, time.Duration(0), backoff.delay)
+}
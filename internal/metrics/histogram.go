@@ -0,0 +1,125 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// DefaultBuckets границы корзин гистограммы по умолчанию, подходят для длительностей в секундах
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+var ErrBucketsMismatch = errors.New("histogram buckets do not match")
+
+// Histogram распределение значений по корзинам
+type Histogram struct {
+	Bounds []float64 `json:"bounds"` // Верхние границы корзин по возрастанию, корзина +Inf подразумевается
+	Counts []uint64  `json:"counts"` // Количество значений в каждой корзине, последняя корзина для +Inf
+	Count  uint64    `json:"count"`  // Общее количество значений
+	Sum    float64   `json:"sum"`    // Сумма всех значений
+}
+
+// NewHistogramBuckets создаёт пустую гистограмму с заданными границами корзин
+func NewHistogramBuckets(bounds []float64) *Histogram {
+	sorted := make([]float64, len(bounds))
+	copy(sorted, bounds)
+	sort.Float64s(sorted)
+
+	return &Histogram{
+		Bounds: sorted,
+		Counts: make([]uint64, len(sorted)+1),
+	}
+}
+
+// Observe добавляет значение в гистограмму
+func (h *Histogram) Observe(value float64) {
+	h.ObserveN(value, 1)
+}
+
+// ObserveN добавляет значение в гистограмму count раз
+func (h *Histogram) ObserveN(value float64, count uint64) {
+	i := sort.SearchFloat64s(h.Bounds, value)
+	h.Counts[i] += count
+	h.Count += count
+	h.Sum += value * float64(count)
+}
+
+// Merge добавляет значения другой гистограммы с такими же границами корзин
+func (h *Histogram) Merge(other *Histogram) error {
+	if !h.sameBounds(other) {
+		return ErrBucketsMismatch
+	}
+
+	for i := range h.Counts {
+		h.Counts[i] += other.Counts[i]
+	}
+	h.Count += other.Count
+	h.Sum += other.Sum
+
+	return nil
+}
+
+// Cumulative возвращает накопленное количество значений, не превышающих каждую границу, включая +Inf
+func (h *Histogram) Cumulative() []uint64 {
+	cumulative := make([]uint64, len(h.Counts))
+	var total uint64
+	for i, count := range h.Counts {
+		total += count
+		cumulative[i] = total
+	}
+
+	return cumulative
+}
+
+// Clone возвращает независимую копию гистограммы
+func (h *Histogram) Clone() *Histogram {
+	clone := &Histogram{
+		Bounds: make([]float64, len(h.Bounds)),
+		Counts: make([]uint64, len(h.Counts)),
+		Count:  h.Count,
+		Sum:    h.Sum,
+	}
+	copy(clone.Bounds, h.Bounds)
+	copy(clone.Counts, h.Counts)
+
+	return clone
+}
+
+// Validate проверяет согласованность корзин и счётчиков гистограммы
+func (h *Histogram) Validate() error {
+	if len(h.Counts) != len(h.Bounds)+1 {
+		return fmt.Errorf("histogram must have %d counts for %d bounds", len(h.Bounds)+1, len(h.Bounds))
+	}
+	for i, bound := range h.Bounds {
+		if math.IsNaN(bound) || math.IsInf(bound, 0) {
+			return errors.New("histogram bounds must be finite")
+		}
+		if i > 0 && bound <= h.Bounds[i-1] {
+			return errors.New("histogram bounds must be strictly increasing")
+		}
+	}
+
+	var total uint64
+	for _, count := range h.Counts {
+		total += count
+	}
+	if total != h.Count {
+		return errors.New("histogram count does not match its buckets")
+	}
+
+	return nil
+}
+
+func (h *Histogram) sameBounds(other *Histogram) bool {
+	if len(h.Bounds) != len(other.Bounds) || len(h.Counts) != len(other.Counts) {
+		return false
+	}
+	for i := range h.Bounds {
+		if h.Bounds[i] != other.Bounds[i] {
+			return false
+		}
+	}
+
+	return true
+}
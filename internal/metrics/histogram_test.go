@@ -0,0 +1,123 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PostScripton/go-metrics-and-alerting-collection/pkg/ddsketch"
+)
+
+func TestHistogram_Observe(t *testing.T) {
+	h := NewHistogramBuckets([]float64{1, 0.1, 10})
+	for _, value := range []float64{0.05, 0.1, 0.5, 5, 50} {
+		h.Observe(value)
+	}
+
+	assert.Equal(t, []float64{0.1, 1, 10}, h.Bounds)
+	assert.Equal(t, []uint64{2, 1, 1, 1}, h.Counts)
+	assert.Equal(t, []uint64{2, 3, 4, 5}, h.Cumulative())
+	assert.Equal(t, uint64(5), h.Count)
+	assert.InDelta(t, 55.65, h.Sum, 1e-9)
+	assert.NoError(t, h.Validate())
+}
+
+func TestHistogram_ObserveN(t *testing.T) {
+	h := NewHistogramBuckets([]float64{1, 10})
+	h.ObserveN(5, 1000)
+	h.ObserveN(50, 0)
+
+	assert.Equal(t, []uint64{0, 1000, 0}, h.Counts)
+	assert.Equal(t, uint64(1000), h.Count)
+	assert.Equal(t, float64(5000), h.Sum)
+}
+
+func TestUpdate_Histogram(t *testing.T) {
+	first := NewHistogram("Latency", []float64{0.1, 1})
+	first.Histogram.Observe(0.05)
+	second := NewHistogram("Latency", []float64{0.1, 1})
+	second.Histogram.Observe(0.5)
+	second.Histogram.Observe(2)
+
+	stored := New(StringHistogramType, "Latency")
+	require.NoError(t, Update(stored, first))
+	require.NoError(t, Update(stored, second))
+
+	assert.Equal(t, []uint64{1, 1, 1}, stored.Histogram.Counts)
+	assert.Equal(t, uint64(3), stored.Histogram.Count)
+	assert.Equal(t, []uint64{1, 0, 0}, first.Histogram.Counts, "the first update must not be modified")
+
+	rebucketed := NewHistogram("Latency", []float64{5})
+	rebucketed.Histogram.Observe(1)
+	assert.ErrorIs(t, Update(stored, rebucketed), ErrBucketsMismatch)
+	assert.Equal(t, []float64{0.1, 1}, stored.Histogram.Bounds, "histogram with other buckets keeps the stored one")
+	assert.Equal(t, []uint64{1, 1, 1}, stored.Histogram.Counts)
+}
+
+func TestUpdate_Summary(t *testing.T) {
+	first, err := NewSummary("Latency", 0.01)
+	require.NoError(t, err)
+	first.Summary.Add(10)
+	second, err := NewSummary("Latency", 0.01)
+	require.NoError(t, err)
+	second.Summary.Add(20)
+	second.Summary.Add(30)
+
+	stored := New(StringSummaryType, "Latency")
+	require.NoError(t, Update(stored, first))
+	require.NoError(t, Update(stored, second))
+
+	assert.Equal(t, uint64(3), stored.Summary.Count)
+	assert.Equal(t, uint64(1), first.Summary.Count, "the first update must not be modified")
+	assert.InEpsilon(t, 20, stored.Quantiles(0.5)["0.5"], 0.01)
+
+	_, err = stored.Float()
+	assert.ErrorIs(t, err, ErrNotScalar)
+
+	coarse, err := NewSummary("Latency", 0.05)
+	require.NoError(t, err)
+	coarse.Summary.Add(1000)
+	err = Update(stored, coarse)
+	assert.ErrorIs(t, err, ddsketch.ErrAccuracyMismatch)
+	assert.True(t, IsIncompatible(err))
+	assert.Equal(t, uint64(3), stored.Summary.Count, "summary with other accuracy keeps the stored one")
+}
+
+func TestHistogram_Validate(t *testing.T) {
+	tests := []struct {
+		name      string
+		histogram Histogram
+		wantErr   bool
+	}{
+		{
+			name:      "valid",
+			histogram: Histogram{Bounds: []float64{1, 2}, Counts: []uint64{1, 0, 2}, Count: 3, Sum: 10},
+		},
+		{
+			name:      "wrong number of counts",
+			histogram: Histogram{Bounds: []float64{1, 2}, Counts: []uint64{1, 0}, Count: 1},
+			wantErr:   true,
+		},
+		{
+			name:      "unsorted bounds",
+			histogram: Histogram{Bounds: []float64{2, 1}, Counts: []uint64{0, 0, 0}},
+			wantErr:   true,
+		},
+		{
+			name:      "count mismatch",
+			histogram: Histogram{Bounds: []float64{1}, Counts: []uint64{1, 1}, Count: 5},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.histogram.Validate()
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -1,28 +1,45 @@
 package metrics
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
 
+	"github.com/PostScripton/go-metrics-and-alerting-collection/pkg/ddsketch"
 	"github.com/PostScripton/go-metrics-and-alerting-collection/pkg/hashing"
 )
 
 // Типы метрик
 const (
-	StringCounterType = "counter" // Счётчик
-	StringGaugeType   = "gauge"   // Измеритель
+	StringCounterType   = "counter"   // Счётчик
+	StringGaugeType     = "gauge"     // Измеритель
+	StringHistogramType = "histogram" // Гистограмма
+	StringSummaryType   = "summary"   // Сводка квантилей
 )
 
+// DefaultQuantiles квантили, которые сервер возвращает для сводки по умолчанию
+var DefaultQuantiles = []float64{0.5, 0.9, 0.99}
+
 // Metrics объект для хранения информации о метрике
 type Metrics struct {
-	ID    string   `json:"id"`              // Название метрики
-	Type  string   `json:"type"`            // Тип метрики
-	Delta *int64   `json:"delta,omitempty"` // (counter) Дельта, на которую изменилась метрика
-	Value *float64 `json:"value,omitempty"` // (gauge) Новое значение метрики
-	Hash  string   `json:"hash,omitempty"`  // Захэшированное значение метрики с помощью HMAC и сохранённое в hex
+	ID        string            `json:"id"`                  // Название метрики
+	Type      string            `json:"type"`                // Тип метрики
+	Labels    map[string]string `json:"labels,omitempty"`    // Метки, например, host, env, service. Входят в идентификатор метрики
+	Delta     *int64            `json:"delta,omitempty"`     // (counter) Дельта, на которую изменилась метрика
+	Value     *float64          `json:"value,omitempty"`     // (gauge) Новое значение метрики
+	Histogram *Histogram        `json:"histogram,omitempty"` // (histogram) Значения, распределённые по корзинам
+	Summary   *ddsketch.Sketch  `json:"summary,omitempty"`   // (summary) Квантильный скетч значений
+	Hash      string            `json:"hash,omitempty"`      // Захэшированное значение метрики с помощью HMAC и сохранённое в hex
 }
 
 var ErrNoValue = errors.New("no value")
+var ErrEmptyID = errors.New("empty metric id")
+var ErrUnsupportedType = errors.New("unsupported metric type")
+var ErrNotScalar = errors.New("metric has no scalar value")
 
 func New(t string, name string) *Metrics {
 	return &Metrics{
@@ -47,12 +64,94 @@ func NewGauge(name string, value float64) *Metrics {
 	}
 }
 
-// Update позволяет обновить старую метрику значениями из новой метрики
-func Update(old *Metrics, new *Metrics) {
-	old.ID = new.ID
-	old.Type = new.Type
-	old.Hash = new.Hash
+// NewHistogram создаёт гистограмму с заданными границами корзин
+func NewHistogram(name string, bounds []float64) *Metrics {
+	return &Metrics{
+		ID:        name,
+		Type:      StringHistogramType,
+		Histogram: NewHistogramBuckets(bounds),
+	}
+}
+
+// NewSummary создаёт сводку с квантильным скетчем заданной относительной точности
+func NewSummary(name string, relativeAccuracy float64) (*Metrics, error) {
+	sketch, err := ddsketch.New(relativeAccuracy)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Metrics{
+		ID:      name,
+		Type:    StringSummaryType,
+		Summary: sketch,
+	}, nil
+}
+
+// Key возвращает идентификатор метрики в хранилище: название и отсортированные метки
+func Key(id string, labels map[string]string) string {
+	if len(labels) == 0 {
+		return id
+	}
+
+	return fmt.Sprintf("%s{%s}", id, formatLabels(labels))
+}
+
+// ParseKey разбирает идентификатор метрики в хранилище, полученный из Key, на название и метки
+func ParseKey(key string) (string, map[string]string, error) {
+	id, rest, found := strings.Cut(key, "{")
+	if !found {
+		return key, nil, nil
+	}
+
+	labels := make(map[string]string)
+	for {
+		var name string
+		if name, rest, found = strings.Cut(rest, "="); !found || name == "" {
+			return "", nil, fmt.Errorf("invalid metric key %q", key)
+		}
+		quoted, err := strconv.QuotedPrefix(rest)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid metric key %q: %w", key, err)
+		}
+		if labels[name], err = strconv.Unquote(quoted); err != nil {
+			return "", nil, fmt.Errorf("invalid metric key %q: %w", key, err)
+		}
 
+		rest = rest[len(quoted):]
+		switch {
+		case rest == "}":
+			return id, labels, nil
+		case strings.HasPrefix(rest, ","):
+			rest = rest[1:]
+		default:
+			return "", nil, fmt.Errorf("invalid metric key %q", key)
+		}
+	}
+}
+
+// Key возвращает идентификатор метрики в хранилище: название и отсортированные метки
+func (m *Metrics) Key() string {
+	return Key(m.ID, m.Labels)
+}
+
+// WithLabels задаёт метки метрике и возвращает её
+func (m *Metrics) WithLabels(labels map[string]string) *Metrics {
+	if len(labels) == 0 {
+		m.Labels = nil
+		return m
+	}
+
+	m.Labels = make(map[string]string, len(labels))
+	for name, value := range labels {
+		m.Labels[name] = value
+	}
+
+	return m
+}
+
+// Update позволяет обновить старую метрику значениями из новой метрики.
+// Если гистограмму или сводку нельзя объединить с сохранённой, то старая метрика не изменяется
+func Update(old *Metrics, new *Metrics) error {
 	switch new.Type {
 	case StringCounterType:
 		var delta int64
@@ -65,21 +164,107 @@ func Update(old *Metrics, new *Metrics) {
 		old.Delta = &delta
 	case StringGaugeType:
 		old.Value = new.Value
+	case StringHistogramType:
+		// гистограмма и сводка old могут быть общими с копиями метрики, выданными читателям,
+		// поэтому изменяются их копии
+		if new.Histogram != nil {
+			merged := new.Histogram.Clone()
+			if old.Histogram != nil {
+				merged = old.Histogram.Clone()
+				if err := merged.Merge(new.Histogram); err != nil {
+					return err
+				}
+			}
+			old.Histogram = merged
+		}
+	case StringSummaryType:
+		if new.Summary != nil {
+			merged := new.Summary.Clone()
+			if old.Summary != nil {
+				merged = old.Summary.Clone()
+				if err := merged.Merge(new.Summary); err != nil {
+					return err
+				}
+			}
+			old.Summary = merged
+		}
 	}
+
+	old.ID = new.ID
+	old.Type = new.Type
+	old.Labels = new.Labels
+	old.Hash = new.Hash
+
+	return nil
+}
+
+// IsIncompatible проверяет, что ошибка Update вызвана значением, которое нельзя объединить с сохранённым
+func IsIncompatible(err error) bool {
+	return errors.Is(err, ErrBucketsMismatch) || errors.Is(err, ddsketch.ErrAccuracyMismatch)
+}
+
+// IsSupportedType проверяет, поддерживается ли тип метрики
+func IsSupportedType(metricType string) bool {
+	switch metricType {
+	case StringCounterType, StringGaugeType, StringHistogramType, StringSummaryType:
+		return true
+	}
+	return false
 }
 
 // Validate проверяет корректность полей метрики
 func (m *Metrics) Validate() (bool, error) {
 	if m.ID == "" {
-		return false, errors.New("empty metric id")
+		return false, ErrEmptyID
+	}
+	if !IsSupportedType(m.Type) {
+		return false, ErrUnsupportedType
 	}
-	if m.Type != StringCounterType && m.Type != StringGaugeType {
-		return false, errors.New("unsupported metric type")
+	if m.Histogram != nil {
+		if err := m.Histogram.Validate(); err != nil {
+			return false, err
+		}
+	}
+	if m.Summary != nil {
+		if err := m.Summary.Validate(); err != nil {
+			return false, err
+		}
+	}
+	for name := range m.Labels {
+		if name == "" {
+			return false, errors.New("empty label name")
+		}
 	}
 
 	return true, nil
 }
 
+// HasValue проверяет, задано ли у метрики значение её типа
+func (m *Metrics) HasValue() bool {
+	switch m.Type {
+	case StringCounterType:
+		return m.Delta != nil
+	case StringGaugeType:
+		return m.Value != nil
+	case StringHistogramType:
+		return m.Histogram != nil
+	case StringSummaryType:
+		return m.Summary != nil
+	}
+	return false
+}
+
+// ValidateWithValue проверяет корректность полей метрики и наличие значения, как у метрики для сохранения
+func (m *Metrics) ValidateWithValue() error {
+	if valid, err := m.Validate(); !valid {
+		return err
+	}
+	if !m.HasValue() {
+		return ErrNoValue
+	}
+	return nil
+}
+
 // ToHash хэширует метрику с помощью hashing.Signer
 func (m *Metrics) ToHash(signer hashing.Signer, key string) []byte {
 	var data string
@@ -88,6 +273,18 @@ func (m *Metrics) ToHash(signer hashing.Signer, key string) []byte {
 		data = fmt.Sprintf("%s:%s:%d", m.ID, m.Type, *m.Delta)
 	case StringGaugeType:
 		data = fmt.Sprintf("%s:%s:%f", m.ID, m.Type, *m.Value)
+	case StringHistogramType:
+		if h := m.Histogram; h != nil {
+			data = fmt.Sprintf("%s:%s:%d:%f:%v:%v", m.ID, m.Type, h.Count, h.Sum, h.Bounds, h.Counts)
+		}
+	case StringSummaryType:
+		if m.Summary != nil {
+			sketch, _ := json.Marshal(m.Summary)
+			data = fmt.Sprintf("%s:%s:%s", m.ID, m.Type, sketch)
+		}
+	}
+	if len(m.Labels) > 0 {
+		data = fmt.Sprintf("%s:%s", data, formatLabels(m.Labels))
 	}
 
 	return signer.Hash(data, key)
@@ -104,3 +301,55 @@ func (m *Metrics) ValidHash(signer hashing.Signer, hash string, key string) bool
 
 	return signer.ValidHash(sign, hash)
 }
+
+// Float возвращает числовое значение метрики независимо от её типа
+func (m *Metrics) Float() (float64, error) {
+	switch m.Type {
+	case StringCounterType:
+		if m.Delta == nil {
+			return 0, ErrNoValue
+		}
+		return float64(*m.Delta), nil
+	case StringGaugeType:
+		if m.Value == nil {
+			return 0, ErrNoValue
+		}
+		return *m.Value, nil
+	case StringHistogramType, StringSummaryType:
+		return 0, ErrNotScalar
+	}
+
+	return 0, errors.New("unsupported metric type")
+}
+
+func formatLabels(labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", name, labels[name]))
+	}
+
+	return strings.Join(pairs, ",")
+}
+
+// Quantiles оценивает квантили сводки. Ключ - квантиль в виде строки, например, "0.99".
+// Квантили пустой сводки не возвращаются
+func (m *Metrics) Quantiles(quantiles ...float64) map[string]float64 {
+	if m.Summary == nil || m.Summary.Count == 0 {
+		return nil
+	}
+
+	result := make(map[string]float64, len(quantiles))
+	for _, q := range quantiles {
+		if value := m.Summary.Quantile(q); !math.IsNaN(value) {
+			result[strconv.FormatFloat(q, 'g', -1, 64)] = value
+		}
+	}
+
+	return result
+}
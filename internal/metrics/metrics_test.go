@@ -0,0 +1,129 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PostScripton/go-metrics-and-alerting-collection/pkg/hashing/hmac"
+)
+
+func TestMetrics_Key(t *testing.T) {
+	tests := []struct {
+		name   string
+		metric *Metrics
+		want   string
+	}{
+		{
+			name:   "without labels",
+			metric: NewGauge("Alloc", 1),
+			want:   "Alloc",
+		},
+		{
+			name:   "labels are sorted",
+			metric: NewGauge("Alloc", 1).WithLabels(map[string]string{"host": "web-1", "env": "prod"}),
+			want:   `Alloc{env="prod",host="web-1"}`,
+		},
+		{
+			name:   "empty labels",
+			metric: NewGauge("Alloc", 1).WithLabels(map[string]string{}),
+			want:   "Alloc",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.metric.Key())
+		})
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		key        string
+		wantID     string
+		wantLabels map[string]string
+		wantErr    bool
+	}{
+		{
+			name:   "without labels",
+			key:    "Alloc",
+			wantID: "Alloc",
+		},
+		{
+			name:       "labels",
+			key:        `Alloc{env="prod",host="web-1"}`,
+			wantID:     "Alloc",
+			wantLabels: map[string]string{"env": "prod", "host": "web-1"},
+		},
+		{
+			name:       "escaped value",
+			key:        NewGauge("Alloc", 1).WithLabels(map[string]string{"cmd": `sh -c "a,b}"`}).Key(),
+			wantID:     "Alloc",
+			wantLabels: map[string]string{"cmd": `sh -c "a,b}"`},
+		},
+		{
+			name:    "unquoted value",
+			key:     "Alloc{env=prod}",
+			wantErr: true,
+		},
+		{
+			name:    "unclosed labels",
+			key:     `Alloc{env="prod"`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, labels, err := ParseKey(tt.key)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantID, id)
+			assert.Equal(t, tt.wantLabels, labels)
+		})
+	}
+}
+
+func TestMetrics_ValidateWithValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		metric  *Metrics
+		wantErr error
+	}{
+		{name: "Counter", metric: NewCounter("PollCount", 1)},
+		{name: "Gauge", metric: NewGauge("Alloc", 1)},
+		{name: "Histogram", metric: NewHistogram("Latency", []float64{1})},
+		{name: "Counter without delta", metric: New(StringCounterType, "PollCount"), wantErr: ErrNoValue},
+		{name: "Summary without sketch", metric: New(StringSummaryType, "Latency"), wantErr: ErrNoValue},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.metric.ValidateWithValue()
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+
+	assert.Error(t, New("set", "Users").ValidateWithValue(), "the metric is validated first")
+}
+
+func TestMetrics_ToHexHash(t *testing.T) {
+	signer := hmac.NewHmacSigner()
+	key := "secret"
+
+	plain := NewCounter("PollCount", 5)
+	assert.Equal(t, signer.HashToHex(signer.Hash("PollCount:counter:5", key)), plain.ToHexHash(signer, key))
+
+	labelled := NewCounter("PollCount", 5).WithLabels(map[string]string{"host": "web-1"})
+	assert.NotEqual(t, plain.ToHexHash(signer, key), labelled.ToHexHash(signer, key))
+	assert.True(t, labelled.ValidHash(signer, labelled.ToHexHash(signer, key), key))
+
+	other := NewCounter("PollCount", 5).WithLabels(map[string]string{"host": "web-2"})
+	assert.False(t, other.ValidHash(signer, labelled.ToHexHash(signer, key), key))
+}
@@ -0,0 +1,208 @@
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/metrics"
+)
+
+// CgroupName имя источника метрик cgroup v2
+const CgroupName = "cgroup"
+
+// cpuStatCounters поля cpu.stat и соответствующие им счётчики
+var cpuStatCounters = map[string]string{
+	"usage_usec":     "CgroupCPUUsageUsec",
+	"user_usec":      "CgroupCPUUserUsec",
+	"system_usec":    "CgroupCPUSystemUsec",
+	"nr_periods":     "CgroupCPUPeriods",
+	"nr_throttled":   "CgroupCPUThrottledPeriods",
+	"throttled_usec": "CgroupCPUThrottledUsec",
+}
+
+// ioStatCounters поля io.stat и соответствующие им счётчики
+var ioStatCounters = map[string]string{
+	"rbytes": "CgroupIOReadBytes",
+	"wbytes": "CgroupIOWriteBytes",
+	"rios":   "CgroupIOReadOps",
+	"wios":   "CgroupIOWriteOps",
+}
+
+// CgroupCollector читает файлы cgroup v2 из директории группы: memory.current, memory.max и pids.current
+// отправляются датчиками, а cpu.stat и io.stat — приростом счётчиков с предыдущего сбора.
+// Счётчики io.stat помечаются меткой device в формате major:minor. Ограничение max в memory.max и pids.max
+// отправляется нулём, чтобы на сервере не осталось снятое ограничение.
+// Отсутствующие файлы пропускаются, так как контроллер может быть не включён для группы
+type CgroupCollector struct {
+	interval time.Duration
+	path     string
+
+	mu   sync.Mutex
+	prev map[string]uint64
+}
+
+func NewCgroupCollector(interval time.Duration, path string) *CgroupCollector {
+	return &CgroupCollector{
+		interval: interval,
+		path:     path,
+	}
+}
+
+func (c *CgroupCollector) Name() string {
+	return CgroupName
+}
+
+func (c *CgroupCollector) Interval() time.Duration {
+	return c.interval
+}
+
+func (c *CgroupCollector) Collect(_ context.Context) ([]metrics.Metrics, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	prev := c.prev
+	c.prev = make(map[string]uint64)
+
+	var collection []metrics.Metrics
+	var errs []error
+	found := false
+
+	for _, gauge := range []struct {
+		file string
+		id   string
+	}{
+		{file: "memory.current", id: "CgroupMemoryCurrentBytes"},
+		{file: "memory.max", id: "CgroupMemoryMaxBytes"},
+		{file: "pids.current", id: "CgroupPids"},
+		{file: "pids.max", id: "CgroupPidsMax"},
+	} {
+		content, err := c.read(gauge.file)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		found = true
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		value := strings.TrimSpace(string(content))
+		if value == "max" {
+			// ограничение не задано
+			value = "0"
+		}
+		parsed, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("parsing %s: %w", gauge.file, err))
+			continue
+		}
+		collection = append(collection, *metrics.NewGauge(gauge.id, float64(parsed)))
+	}
+
+	if content, err := c.read("cpu.stat"); !errors.Is(err, os.ErrNotExist) {
+		found = true
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			collection = append(collection, c.cpuStat(content, prev)...)
+		}
+	}
+
+	if content, err := c.read("io.stat"); !errors.Is(err, os.ErrNotExist) {
+		found = true
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			collection = append(collection, c.ioStat(content, prev)...)
+		}
+	}
+
+	if !found {
+		return nil, fmt.Errorf("no cgroup v2 files found in %s", c.path)
+	}
+	return collection, joinErrors(errs)
+}
+
+func (c *CgroupCollector) read(name string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(c.path, name))
+}
+
+// cpuStat разбирает строки вида "usage_usec 123"
+func (c *CgroupCollector) cpuStat(content []byte, prev map[string]uint64) []metrics.Metrics {
+	var collection []metrics.Metrics
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		id, ok := cpuStatCounters[fields[0]]
+		if !ok {
+			continue
+		}
+		value, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		if metric, ok := c.delta(metrics.NewCounter(id, 0), value, prev); ok {
+			collection = append(collection, *metric)
+		}
+	}
+
+	return collection
+}
+
+// ioStat разбирает строки вида "8:0 rbytes=1 wbytes=2 rios=3 wios=4 dbytes=0 dios=0"
+func (c *CgroupCollector) ioStat(content []byte, prev map[string]uint64) []metrics.Metrics {
+	var collection []metrics.Metrics
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+
+		labels := map[string]string{"device": fields[0]}
+		for _, field := range fields[1:] {
+			name, rawValue, found := strings.Cut(field, "=")
+			id, ok := ioStatCounters[name]
+			if !found || !ok {
+				continue
+			}
+			value, err := strconv.ParseUint(rawValue, 10, 64)
+			if err != nil {
+				continue
+			}
+			if metric, ok := c.delta(metrics.NewCounter(id, 0).WithLabels(labels), value, prev); ok {
+				collection = append(collection, *metric)
+			}
+		}
+	}
+
+	return collection
+}
+
+// delta запоминает текущее значение счётчика и возвращает прирост с предыдущего сбора.
+// Первое значение и сброс счётчика служат новой точкой отсчёта
+func (c *CgroupCollector) delta(metric *metrics.Metrics, value uint64, prev map[string]uint64) (*metrics.Metrics, bool) {
+	key := metric.Key()
+	c.prev[key] = value
+
+	old, ok := prev[key]
+	if !ok || value < old {
+		return nil, false
+	}
+
+	delta := int64(value - old)
+	metric.Delta = &delta
+	return metric, true
+}
@@ -0,0 +1,86 @@
+package collector
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/metrics"
+)
+
+func writeCgroupFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+}
+
+func TestCgroupCollector_Collect(t *testing.T) {
+	dir := t.TempDir()
+	writeCgroupFiles(t, dir, map[string]string{
+		"memory.current": "104857600\n",
+		"memory.max":     "max\n",
+		"pids.current":   "12\n",
+		"cpu.stat":       "usage_usec 1000\nuser_usec 600\nsystem_usec 400\nnr_periods 10\nnr_throttled 1\nthrottled_usec 50\n",
+		"io.stat":        "8:0 rbytes=4096 wbytes=0 rios=1 wios=0 dbytes=0 dios=0\n",
+	})
+
+	c := NewCgroupCollector(0, dir)
+
+	first, err := c.Collect(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []metrics.Metrics{
+		*metrics.NewGauge("CgroupMemoryCurrentBytes", 104857600),
+		*metrics.NewGauge("CgroupMemoryMaxBytes", 0),
+		*metrics.NewGauge("CgroupPids", 12),
+	}, first, "unlimited memory.max is zero, counters only set the baseline")
+
+	writeCgroupFiles(t, dir, map[string]string{
+		"memory.max": "536870912\n",
+		"cpu.stat":   "usage_usec 3500\nuser_usec 2000\nsystem_usec 1500\nnr_periods 20\nnr_throttled 4\nthrottled_usec 250\n",
+		"io.stat":    "8:0 rbytes=12288 wbytes=8192 rios=3 wios=2 dbytes=0 dios=0\n253:1 rbytes=1 wbytes=1 rios=1 wios=1\n",
+	})
+
+	second, err := c.Collect(context.Background())
+	require.NoError(t, err)
+	byKey := make(map[string]metrics.Metrics, len(second))
+	for _, m := range second {
+		byKey[m.Key()] = m
+	}
+
+	assert.Equal(t, float64(536870912), *byKey["CgroupMemoryMaxBytes"].Value)
+	assert.Equal(t, int64(2500), *byKey["CgroupCPUUsageUsec"].Delta)
+	assert.Equal(t, int64(1400), *byKey["CgroupCPUUserUsec"].Delta)
+	assert.Equal(t, int64(1100), *byKey["CgroupCPUSystemUsec"].Delta)
+	assert.Equal(t, int64(10), *byKey["CgroupCPUPeriods"].Delta)
+	assert.Equal(t, int64(3), *byKey["CgroupCPUThrottledPeriods"].Delta)
+	assert.Equal(t, int64(200), *byKey["CgroupCPUThrottledUsec"].Delta)
+	assert.Equal(t, int64(8192), *byKey[`CgroupIOReadBytes{device="8:0"}`].Delta)
+	assert.Equal(t, int64(8192), *byKey[`CgroupIOWriteBytes{device="8:0"}`].Delta)
+	assert.Equal(t, int64(2), *byKey[`CgroupIOReadOps{device="8:0"}`].Delta)
+	assert.Equal(t, int64(2), *byKey[`CgroupIOWriteOps{device="8:0"}`].Delta)
+	assert.NotContains(t, byKey, `CgroupIOReadBytes{device="253:1"}`, "a new device is only a baseline")
+
+	writeCgroupFiles(t, dir, map[string]string{"memory.max": "max\n"})
+	third, err := c.Collect(context.Background())
+	require.NoError(t, err)
+	assert.Contains(t, third, *metrics.NewGauge("CgroupMemoryMaxBytes", 0), "a removed limit replaces the old one")
+}
+
+func TestCgroupCollector_MissingFiles(t *testing.T) {
+	_, err := NewCgroupCollector(0, t.TempDir()).Collect(context.Background())
+	assert.Error(t, err, "an empty directory is not a cgroup")
+
+	dir := t.TempDir()
+	writeCgroupFiles(t, dir, map[string]string{
+		"memory.current": "not a number",
+		"pids.current":   "3",
+	})
+	collection, err := NewCgroupCollector(0, dir).Collect(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, []metrics.Metrics{*metrics.NewGauge("CgroupPids", 3)}, collection)
+}
@@ -0,0 +1,92 @@
+// Package collector содержит источники метрик агента. Каждый источник собирает свой набор метрик
+// со своим интервалом и регистрируется в реестре, из которого агент запускает сбор
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/metrics"
+)
+
+// Collector источник метрик
+type Collector interface {
+	Name() string                                           // Уникальное имя источника
+	Interval() time.Duration                                // Интервал сбора, 0 означает интервал агента по умолчанию
+	Collect(ctx context.Context) ([]metrics.Metrics, error) // Собирает метрики
+}
+
+// Registry набор зарегистрированных источников метрик
+type Registry struct {
+	mu         sync.RWMutex
+	collectors map[string]Collector
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		mu:         sync.RWMutex{},
+		collectors: make(map[string]Collector),
+	}
+}
+
+// Register добавляет источник в реестр. Имена источников не должны повторяться
+func (r *Registry) Register(collector Collector) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	name := collector.Name()
+	if name == "" {
+		return fmt.Errorf("collector has an empty name")
+	}
+	if _, ok := r.collectors[name]; ok {
+		return fmt.Errorf("collector %q is already registered", name)
+	}
+	r.collectors[name] = collector
+
+	return nil
+}
+
+// Get возвращает источник по имени
+func (r *Registry) Get(name string) (Collector, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	collector, ok := r.collectors[name]
+	return collector, ok
+}
+
+// Collectors возвращает все источники, отсортированные по имени
+func (r *Registry) Collectors() []Collector {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	collectors := make([]Collector, 0, len(r.collectors))
+	for _, collector := range r.collectors {
+		collectors = append(collectors, collector)
+	}
+	sort.Slice(collectors, func(i, j int) bool {
+		return collectors[i].Name() < collectors[j].Name()
+	})
+
+	return collectors
+}
+
+// joinErrors объединяет ошибки частично неудавшегося сбора в одну. Первая ошибка остаётся доступной через errors.Is
+func joinErrors(errs []error) error {
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	}
+
+	messages := make([]string, 0, len(errs)-1)
+	for _, err := range errs[1:] {
+		messages = append(messages, err.Error())
+	}
+	return fmt.Errorf("%w; %s", errs[0], strings.Join(messages, "; "))
+}
@@ -0,0 +1,53 @@
+package collector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/metrics"
+)
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry()
+	require.NoError(t, registry.Register(NewSystemCollector(time.Second)))
+	require.NoError(t, registry.Register(NewRuntimeCollector(0)))
+
+	assert.Error(t, registry.Register(NewRuntimeCollector(time.Minute)), "names must be unique")
+
+	names := make([]string, 0)
+	for _, c := range registry.Collectors() {
+		names = append(names, c.Name())
+	}
+	assert.Equal(t, []string{RuntimeName, SystemName}, names)
+
+	c, ok := registry.Get(SystemName)
+	require.True(t, ok)
+	assert.Equal(t, time.Second, c.Interval())
+
+	_, ok = registry.Get("unknown")
+	assert.False(t, ok)
+}
+
+func TestRuntimeCollector_Collect(t *testing.T) {
+	collection, err := NewRuntimeCollector(0).Collect(context.Background())
+	require.NoError(t, err)
+
+	byID := make(map[string]metrics.Metrics, len(collection))
+	for _, m := range collection {
+		valid, errValidate := m.Validate()
+		assert.True(t, valid, errValidate)
+		byID[m.ID] = m
+	}
+
+	require.Contains(t, byID, "PollCount")
+	assert.Equal(t, metrics.StringCounterType, byID["PollCount"].Type)
+	assert.Equal(t, int64(1), *byID["PollCount"].Delta)
+	for _, id := range []string{"Alloc", "HeapAlloc", "NumGC", "RandomValue", "TotalAlloc"} {
+		require.Contains(t, byID, id)
+		assert.Equal(t, metrics.StringGaugeType, byID[id].Type)
+	}
+}
@@ -0,0 +1,141 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/cpu"
+	"github.com/shirou/gopsutil/load"
+
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/metrics"
+)
+
+// CPUName имя источника метрик процессора
+const CPUName = "cpu"
+
+// CPUCollector собирает загрузку каждого ядра CPUCoreUtilization с меткой cpu (1..N), средние нагрузки
+// за 1/5/15 минут и время процессора в режимах user/system/iowait/steal в миллисекундах как счётчики.
+// Общую загрузку CPUutilization1 собирает SystemCollector.
+// Загрузка и время считаются по разнице с предыдущим сбором, поэтому первый сбор их не возвращает
+type CPUCollector struct {
+	interval time.Duration
+	times    func(ctx context.Context, perCPU bool) ([]cpu.TimesStat, error)
+	loadAvg  func(ctx context.Context) (*load.AvgStat, error)
+
+	mu       sync.Mutex
+	prevCPUs []cpu.TimesStat
+	reported map[string]float64 // Время в секундах, уже отправленное счётчиками
+}
+
+func NewCPUCollector(interval time.Duration) *CPUCollector {
+	return &CPUCollector{
+		interval: interval,
+		times:    cpu.TimesWithContext,
+		loadAvg:  load.AvgWithContext,
+	}
+}
+
+func (c *CPUCollector) Name() string {
+	return CPUName
+}
+
+func (c *CPUCollector) Interval() time.Duration {
+	return c.interval
+}
+
+func (c *CPUCollector) Collect(ctx context.Context) ([]metrics.Metrics, error) {
+	var collection []metrics.Metrics
+	var errs []error
+
+	if avg, err := c.loadAvg(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("reading load average: %w", err))
+	} else {
+		collection = append(collection,
+			*metrics.NewGauge("Load1", avg.Load1),
+			*metrics.NewGauge("Load5", avg.Load5),
+			*metrics.NewGauge("Load15", avg.Load15),
+		)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if perCPU, err := c.times(ctx, true); err != nil {
+		errs = append(errs, fmt.Errorf("reading per-CPU times: %w", err))
+	} else {
+		collection = append(collection, c.utilization(perCPU)...)
+	}
+
+	if total, err := c.times(ctx, false); err != nil {
+		errs = append(errs, fmt.Errorf("reading CPU times: %w", err))
+	} else if len(total) > 0 {
+		collection = append(collection, c.timeCounters(total[0])...)
+	}
+
+	return collection, joinErrors(errs)
+}
+
+// utilization считает загрузку каждого ядра в процентах с предыдущего сбора
+func (c *CPUCollector) utilization(perCPU []cpu.TimesStat) []metrics.Metrics {
+	prev := c.prevCPUs
+	c.prevCPUs = perCPU
+	if len(prev) != len(perCPU) {
+		return nil
+	}
+
+	collection := make([]metrics.Metrics, 0, len(perCPU))
+	for i, cur := range perCPU {
+		total := totalTime(cur) - totalTime(prev[i])
+		idle := (cur.Idle + cur.Iowait) - (prev[i].Idle + prev[i].Iowait)
+		if total <= 0 || idle < 0 {
+			continue
+		}
+
+		percent := math.Max(0, math.Min(100, (total-idle)/total*100))
+		gauge := metrics.NewGauge("CPUCoreUtilization", percent).WithLabels(map[string]string{"cpu": strconv.Itoa(i + 1)})
+		collection = append(collection, *gauge)
+	}
+
+	return collection
+}
+
+// timeCounters возвращает прирост времени процессора в миллисекундах. Дробная часть
+// не теряется, а переносится на следующий сбор
+func (c *CPUCollector) timeCounters(total cpu.TimesStat) []metrics.Metrics {
+	current := map[string]float64{
+		"CPUTimeUserMs":   total.User,
+		"CPUTimeSystemMs": total.System,
+		"CPUTimeIowaitMs": total.Iowait,
+		"CPUTimeStealMs":  total.Steal,
+	}
+
+	if c.reported == nil {
+		c.reported = current
+		return nil
+	}
+
+	collection := make([]metrics.Metrics, 0, len(current))
+	for _, id := range []string{"CPUTimeUserMs", "CPUTimeSystemMs", "CPUTimeIowaitMs", "CPUTimeStealMs"} {
+		seconds := current[id]
+		if seconds < c.reported[id] {
+			// счётчики ядра сбросились, начинаем отсчёт заново
+			c.reported[id] = seconds
+			continue
+		}
+
+		delta := int64((seconds - c.reported[id]) * 1000)
+		c.reported[id] += float64(delta) / 1000
+		collection = append(collection, *metrics.NewCounter(id, delta))
+	}
+
+	return collection
+}
+
+func totalTime(t cpu.TimesStat) float64 {
+	// guest и guestNice уже входят в user и nice
+	return t.User + t.System + t.Idle + t.Nice + t.Iowait + t.Irq + t.Softirq + t.Steal
+}
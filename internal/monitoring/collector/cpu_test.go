@@ -0,0 +1,92 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/shirou/gopsutil/cpu"
+	"github.com/shirou/gopsutil/load"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/metrics"
+)
+
+func TestCPUCollector_Collect(t *testing.T) {
+	samples := []struct {
+		perCPU []cpu.TimesStat
+		total  cpu.TimesStat
+	}{
+		{
+			perCPU: []cpu.TimesStat{
+				{CPU: "cpu0", User: 10, System: 5, Idle: 85},
+				{CPU: "cpu1", User: 20, System: 10, Idle: 60, Iowait: 10},
+			},
+			total: cpu.TimesStat{CPU: "cpu-total", User: 30, System: 15, Iowait: 10, Steal: 1},
+		},
+		{
+			perCPU: []cpu.TimesStat{
+				// 50 из 100 секунд заняты
+				{CPU: "cpu0", User: 40, System: 25, Idle: 135},
+				// всё время простаивало, включая ожидание ввода-вывода
+				{CPU: "cpu1", User: 20, System: 10, Idle: 140, Iowait: 30},
+			},
+			total: cpu.TimesStat{CPU: "cpu-total", User: 60.0015, System: 35, Iowait: 30, Steal: 1.5},
+		},
+	}
+
+	call := 0
+	c := NewCPUCollector(0)
+	c.loadAvg = func(_ context.Context) (*load.AvgStat, error) {
+		return &load.AvgStat{Load1: 1.5, Load5: 1, Load15: 0.5}, nil
+	}
+	c.times = func(_ context.Context, perCPU bool) ([]cpu.TimesStat, error) {
+		if perCPU {
+			return samples[call].perCPU, nil
+		}
+		defer func() { call++ }()
+		return []cpu.TimesStat{samples[call].total}, nil
+	}
+
+	first, err := c.Collect(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []metrics.Metrics{
+		*metrics.NewGauge("Load1", 1.5),
+		*metrics.NewGauge("Load5", 1),
+		*metrics.NewGauge("Load15", 0.5),
+	}, first, "the first collection has no baseline for deltas")
+
+	second, err := c.Collect(context.Background())
+	require.NoError(t, err)
+	byID := make(map[string]metrics.Metrics, len(second))
+	for _, m := range second {
+		byID[m.Key()] = m
+	}
+
+	assert.InDelta(t, 50, *byID[`CPUCoreUtilization{cpu="1"}`].Value, 1e-9)
+	assert.InDelta(t, 0, *byID[`CPUCoreUtilization{cpu="2"}`].Value, 1e-9)
+	assert.NotContains(t, byID, "CPUutilization1", "the aggregate utilization is reported by the system collector")
+	assert.Equal(t, int64(30001), *byID["CPUTimeUserMs"].Delta)
+	assert.Equal(t, int64(20000), *byID["CPUTimeSystemMs"].Delta)
+	assert.Equal(t, int64(20000), *byID["CPUTimeIowaitMs"].Delta)
+	assert.Equal(t, int64(500), *byID["CPUTimeStealMs"].Delta)
+}
+
+func TestCPUCollector_PartialFailure(t *testing.T) {
+	errNoLoad := errors.New("load average is not supported")
+	c := NewCPUCollector(0)
+	c.loadAvg = func(_ context.Context) (*load.AvgStat, error) {
+		return nil, errNoLoad
+	}
+	c.times = func(_ context.Context, _ bool) ([]cpu.TimesStat, error) {
+		return []cpu.TimesStat{{User: 1, Idle: 1}}, nil
+	}
+
+	_, err := c.Collect(context.Background())
+	assert.ErrorIs(t, err, errNoLoad)
+
+	collection, err := c.Collect(context.Background())
+	assert.ErrorIs(t, err, errNoLoad)
+	assert.NotEmpty(t, collection, "CPU times must be reported even without load average")
+}
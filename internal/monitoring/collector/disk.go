@@ -0,0 +1,166 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/disk"
+
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/metrics"
+)
+
+// DiskName имя источника метрик дисков и файловых систем
+const DiskName = "disk"
+
+// DiskFilters отбирают точки монтирования, типы файловых систем и устройства
+type DiskFilters struct {
+	MountPoints Filter
+	FSTypes     Filter
+	Devices     Filter
+}
+
+// DiskCollector собирает заполненность каждой точки монтирования в байтах и inode
+// с метками mountpoint, device и fstype, а также прирост прочитанных и записанных байт
+// и операций по каждому устройству с меткой device
+type DiskCollector struct {
+	interval   time.Duration
+	filters    DiskFilters
+	partitions func(ctx context.Context, all bool) ([]disk.PartitionStat, error)
+	usage      func(ctx context.Context, path string) (*disk.UsageStat, error)
+	ioCounters func(ctx context.Context, names ...string) (map[string]disk.IOCountersStat, error)
+
+	mu   sync.Mutex
+	prev map[string]disk.IOCountersStat
+}
+
+func NewDiskCollector(interval time.Duration, filters DiskFilters) (*DiskCollector, error) {
+	for _, filter := range []Filter{filters.MountPoints, filters.FSTypes, filters.Devices} {
+		if err := filter.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	return &DiskCollector{
+		interval:   interval,
+		filters:    filters,
+		partitions: disk.PartitionsWithContext,
+		usage:      disk.UsageWithContext,
+		ioCounters: disk.IOCountersWithContext,
+	}, nil
+}
+
+func (c *DiskCollector) Name() string {
+	return DiskName
+}
+
+func (c *DiskCollector) Interval() time.Duration {
+	return c.interval
+}
+
+func (c *DiskCollector) Collect(ctx context.Context) ([]metrics.Metrics, error) {
+	var collection []metrics.Metrics
+	var errs []error
+
+	usage, err := c.collectUsage(ctx)
+	collection = append(collection, usage...)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	io, err := c.collectIO(ctx)
+	collection = append(collection, io...)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	return collection, joinErrors(errs)
+}
+
+func (c *DiskCollector) collectUsage(ctx context.Context) ([]metrics.Metrics, error) {
+	partitions, err := c.partitions(ctx, false)
+	if err != nil {
+		return nil, fmt.Errorf("reading disk partitions: %w", err)
+	}
+
+	var collection []metrics.Metrics
+	var errs []error
+	seen := make(map[string]bool, len(partitions))
+	for _, partition := range partitions {
+		if seen[partition.Mountpoint] ||
+			!c.filters.MountPoints.Match(partition.Mountpoint) ||
+			!c.filters.FSTypes.Match(partition.Fstype) {
+			continue
+		}
+		seen[partition.Mountpoint] = true
+
+		usage, err := c.usage(ctx, partition.Mountpoint)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("reading usage of %s: %w", partition.Mountpoint, err))
+			continue
+		}
+
+		labels := map[string]string{
+			"mountpoint": partition.Mountpoint,
+			"device":     partition.Device,
+			"fstype":     partition.Fstype,
+		}
+		for _, gauge := range []struct {
+			id    string
+			value float64
+		}{
+			{id: "DiskTotalBytes", value: float64(usage.Total)},
+			{id: "DiskUsedBytes", value: float64(usage.Used)},
+			{id: "DiskFreeBytes", value: float64(usage.Free)},
+			{id: "DiskUsedPercent", value: usage.UsedPercent},
+			{id: "DiskInodesTotal", value: float64(usage.InodesTotal)},
+			{id: "DiskInodesUsed", value: float64(usage.InodesUsed)},
+			{id: "DiskInodesFree", value: float64(usage.InodesFree)},
+			{id: "DiskInodesUsedPercent", value: usage.InodesUsedPercent},
+		} {
+			collection = append(collection, *metrics.NewGauge(gauge.id, gauge.value).WithLabels(labels))
+		}
+	}
+
+	return collection, joinErrors(errs)
+}
+
+// collectIO возвращает прирост счётчиков устройств с предыдущего сбора. Устройство, появившееся впервые
+// или со сброшенными счётчиками, служит точкой отсчёта и в этом сборе не отправляется
+func (c *DiskCollector) collectIO(ctx context.Context) ([]metrics.Metrics, error) {
+	counters, err := c.ioCounters(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("reading disk IO counters: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	prev := c.prev
+	c.prev = make(map[string]disk.IOCountersStat, len(counters))
+
+	var collection []metrics.Metrics
+	for name, cur := range counters {
+		if !c.filters.Devices.Match(name) {
+			continue
+		}
+		c.prev[name] = cur
+
+		old, ok := prev[name]
+		if !ok || cur.ReadBytes < old.ReadBytes || cur.WriteBytes < old.WriteBytes ||
+			cur.ReadCount < old.ReadCount || cur.WriteCount < old.WriteCount {
+			continue
+		}
+
+		labels := map[string]string{"device": name}
+		collection = append(collection,
+			*metrics.NewCounter("DiskReadBytes", int64(cur.ReadBytes-old.ReadBytes)).WithLabels(labels),
+			*metrics.NewCounter("DiskWriteBytes", int64(cur.WriteBytes-old.WriteBytes)).WithLabels(labels),
+			*metrics.NewCounter("DiskReadOps", int64(cur.ReadCount-old.ReadCount)).WithLabels(labels),
+			*metrics.NewCounter("DiskWriteOps", int64(cur.WriteCount-old.WriteCount)).WithLabels(labels),
+		)
+	}
+
+	return collection, nil
+}
@@ -0,0 +1,75 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/shirou/gopsutil/disk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/metrics"
+)
+
+func TestDiskCollector_Collect(t *testing.T) {
+	c, err := NewDiskCollector(0, DiskFilters{
+		MountPoints: Filter{Exclude: []string{"/snap/*/*"}},
+		FSTypes:     Filter{Exclude: []string{"tmpfs"}},
+		Devices:     Filter{Include: []string{"sd*"}},
+	})
+	require.NoError(t, err)
+
+	c.partitions = func(_ context.Context, _ bool) ([]disk.PartitionStat, error) {
+		return []disk.PartitionStat{
+			{Device: "/dev/sda1", Mountpoint: "/", Fstype: "ext4"},
+			{Device: "/dev/sdb1", Mountpoint: "/data", Fstype: "xfs"},
+			{Device: "/dev/sdb1", Mountpoint: "/data", Fstype: "xfs"},
+			{Device: "/dev/loop0", Mountpoint: "/snap/core/1", Fstype: "squashfs"},
+			{Device: "tmpfs", Mountpoint: "/run", Fstype: "tmpfs"},
+		}, nil
+	}
+	c.usage = func(_ context.Context, path string) (*disk.UsageStat, error) {
+		if path == "/data" {
+			return nil, errors.New("permission denied")
+		}
+		return &disk.UsageStat{Path: path, Total: 100, Used: 60, Free: 40, UsedPercent: 60, InodesTotal: 10, InodesUsed: 1, InodesFree: 9, InodesUsedPercent: 10}, nil
+	}
+	reads := uint64(1000)
+	c.ioCounters = func(_ context.Context, _ ...string) (map[string]disk.IOCountersStat, error) {
+		reads += 4096
+		return map[string]disk.IOCountersStat{
+			"sda":   {Name: "sda", ReadBytes: reads, WriteBytes: 10, ReadCount: reads / 4096, WriteCount: 1},
+			"loop0": {Name: "loop0", ReadBytes: reads},
+		}, nil
+	}
+
+	first, err := c.Collect(context.Background())
+	assert.Error(t, err, "usage failure of one mount point must be reported")
+
+	byKey := make(map[string]metrics.Metrics, len(first))
+	for _, m := range first {
+		byKey[m.Key()] = m
+	}
+	assert.Len(t, byKey, 8, "only / must be reported, without IO counters on the first collection")
+	root := byKey[`DiskUsedBytes{device="/dev/sda1",fstype="ext4",mountpoint="/"}`]
+	require.NotNil(t, root.Value)
+	assert.Equal(t, float64(60), *root.Value)
+
+	second, _ := c.Collect(context.Background())
+	byKey = make(map[string]metrics.Metrics, len(second))
+	for _, m := range second {
+		byKey[m.Key()] = m
+	}
+	readBytes, ok := byKey[`DiskReadBytes{device="sda"}`]
+	require.True(t, ok)
+	assert.Equal(t, int64(4096), *readBytes.Delta)
+	assert.Equal(t, int64(0), *byKey[`DiskWriteOps{device="sda"}`].Delta)
+	_, ok = byKey[`DiskReadBytes{device="loop0"}`]
+	assert.False(t, ok, "filtered devices must not be reported")
+}
+
+func TestNewDiskCollector_InvalidFilter(t *testing.T) {
+	_, err := NewDiskCollector(0, DiskFilters{Devices: Filter{Include: []string{"[sd"}}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,226 @@
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/metrics"
+)
+
+// ExecName префикс имён источников, запускающих пользовательские команды
+const ExecName = "exec"
+
+// Форматы вывода команды
+const (
+	ExecFormatLine = "line" // Строки "type name value [label=value ...]"
+	ExecFormatJSON = "json" // JSON-массив metrics.Metrics
+)
+
+const defaultExecTimeout = 10 * time.Second
+
+// ExecCommand пользовательская команда, печатающая метрики в stdout
+type ExecCommand struct {
+	Name     string        // Имя команды, источник называется exec:<Name>
+	Command  []string      // Программа и её аргументы, запускается без оболочки
+	Format   string        // Формат вывода, по умолчанию line
+	Timeout  time.Duration // Время на выполнение команды, по умолчанию 10s
+	Dir      string        // Рабочая директория
+	Env      []string      // Дополнительные переменные окружения в формате KEY=value
+	Interval time.Duration // Интервал запуска
+}
+
+// ExecCollector запускает команду и разбирает её вывод. Ненулевой код выхода считается ошибкой,
+// а строки, которые не удалось разобрать, пропускаются с ошибкой, не мешая остальным
+type ExecCollector struct {
+	command ExecCommand
+}
+
+func NewExecCollector(command ExecCommand) (*ExecCollector, error) {
+	if command.Name == "" {
+		return nil, errors.New("exec command has an empty name")
+	}
+	if len(command.Command) == 0 || command.Command[0] == "" {
+		return nil, fmt.Errorf("exec command %q has no program to run", command.Name)
+	}
+	switch command.Format {
+	case "":
+		command.Format = ExecFormatLine
+	case ExecFormatLine, ExecFormatJSON:
+	default:
+		return nil, fmt.Errorf("exec command %q has an unsupported format %q", command.Name, command.Format)
+	}
+	if command.Timeout <= 0 {
+		command.Timeout = defaultExecTimeout
+	}
+
+	return &ExecCollector{
+		command: command,
+	}, nil
+}
+
+func (c *ExecCollector) Name() string {
+	return ExecName + ":" + c.command.Name
+}
+
+func (c *ExecCollector) Interval() time.Duration {
+	return c.command.Interval
+}
+
+func (c *ExecCollector) Collect(ctx context.Context) ([]metrics.Metrics, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.command.Timeout)
+	defer cancel()
+
+	cmd := exec.Command(c.command.Command[0], c.command.Command[1:]...)
+	cmd.Dir = c.command.Dir
+	if len(c.command.Env) > 0 {
+		cmd.Env = append(os.Environ(), c.command.Env...)
+	}
+	// команда запускается в своей группе процессов, чтобы по таймауту завершить и её дочерние процессы
+	setProcessGroup(cmd)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", c.Name(), err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", c.Name(), err)
+	}
+	if err = cmd.Start(); err != nil {
+		return nil, fmt.Errorf("%s: %w", c.Name(), err)
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			killProcessGroup(cmd)
+			// процесс, вышедший из группы, может держать вывод открытым, поэтому чтение прерывается закрытием
+			stdout.Close()
+			stderr.Close()
+		case <-done:
+		}
+	}()
+
+	stderrOutput := make(chan []byte, 1)
+	go func() {
+		output, _ := io.ReadAll(stderr)
+		stderrOutput <- output
+	}()
+	output, _ := io.ReadAll(stdout)
+	errOutput := <-stderrOutput
+
+	if err = cmd.Wait(); err != nil || ctx.Err() != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("%s: timed out after %s", c.Name(), c.command.Timeout)
+		}
+		if err == nil {
+			err = ctx.Err()
+		}
+		return nil, fmt.Errorf("%s: %w: %s", c.Name(), err, strings.TrimSpace(string(errOutput)))
+	}
+
+	if c.command.Format == ExecFormatJSON {
+		return ParseJSONMetrics(output)
+	}
+	return ParseLineMetrics(output)
+}
+
+// ParseLineMetrics разбирает строки вида "type name value [label=value ...]".
+// Пустые строки и строки, начинающиеся с #, пропускаются
+func ParseLineMetrics(output []byte) ([]metrics.Metrics, error) {
+	var collection []metrics.Metrics
+	var errs []error
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		metric, err := parseMetricLine(line)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %w", lineNumber, err))
+			continue
+		}
+		collection = append(collection, *metric)
+	}
+	if err := scanner.Err(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return collection, joinErrors(errs)
+}
+
+func parseMetricLine(line string) (*metrics.Metrics, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 {
+		return nil, fmt.Errorf("expected \"type name value\", got %q", line)
+	}
+
+	var metric *metrics.Metrics
+	switch fields[0] {
+	case metrics.StringCounterType:
+		delta, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid counter value %q", fields[2])
+		}
+		metric = metrics.NewCounter(fields[1], delta)
+	case metrics.StringGaugeType:
+		value, err := strconv.ParseFloat(fields[2], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gauge value %q", fields[2])
+		}
+		metric = metrics.NewGauge(fields[1], value)
+	default:
+		return nil, fmt.Errorf("unsupported metric type %q", fields[0])
+	}
+
+	if len(fields) > 3 {
+		labels := make(map[string]string, len(fields)-3)
+		for _, pair := range fields[3:] {
+			name, value, found := strings.Cut(pair, "=")
+			if !found || name == "" {
+				return nil, fmt.Errorf("invalid label %q, expected name=value", pair)
+			}
+			labels[name] = value
+		}
+		metric.WithLabels(labels)
+	}
+
+	if valid, err := metric.Validate(); !valid {
+		return nil, err
+	}
+	return metric, nil
+}
+
+// ParseJSONMetrics разбирает JSON-массив метрик, пропуская некорректные
+func ParseJSONMetrics(output []byte) ([]metrics.Metrics, error) {
+	var parsed []metrics.Metrics
+	if err := json.Unmarshal(output, &parsed); err != nil {
+		return nil, fmt.Errorf("parsing JSON output: %w", err)
+	}
+
+	collection := make([]metrics.Metrics, 0, len(parsed))
+	var errs []error
+	for i, metric := range parsed {
+		if err := metric.ValidateWithValue(); err != nil {
+			errs = append(errs, fmt.Errorf("metric #%d %q: %w", i, metric.ID, err))
+			continue
+		}
+		collection = append(collection, metric)
+	}
+
+	return collection, joinErrors(errs)
+}
@@ -0,0 +1,133 @@
+package collector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/metrics"
+)
+
+func TestParseLineMetrics(t *testing.T) {
+	collection, err := ParseLineMetrics([]byte(`
+# queue depths
+gauge QueueDepth 42 queue=emails
+counter ProcessedJobs 7
+gauge CertExpiryDays 12.5 host=example.com
+
+histogram Latency 1
+gauge Broken value
+counter Bad 1 =x
+`))
+	assert.Error(t, err, "invalid lines must be reported")
+	assert.Equal(t, []metrics.Metrics{
+		*metrics.NewGauge("QueueDepth", 42).WithLabels(map[string]string{"queue": "emails"}),
+		*metrics.NewCounter("ProcessedJobs", 7),
+		*metrics.NewGauge("CertExpiryDays", 12.5).WithLabels(map[string]string{"host": "example.com"}),
+	}, collection)
+}
+
+func TestParseJSONMetrics(t *testing.T) {
+	collection, err := ParseJSONMetrics([]byte(`[
+		{"id": "QueueDepth", "type": "gauge", "value": 3},
+		{"id": "Processed", "type": "counter", "delta": 2, "labels": {"queue": "sms"}},
+		{"id": "NoValue", "type": "gauge"},
+		{"id": "", "type": "gauge", "value": 1}
+	]`))
+	assert.Error(t, err)
+	assert.Equal(t, []metrics.Metrics{
+		*metrics.NewGauge("QueueDepth", 3),
+		*metrics.NewCounter("Processed", 2).WithLabels(map[string]string{"queue": "sms"}),
+	}, collection)
+
+	_, err = ParseJSONMetrics([]byte(`gauge QueueDepth 3`))
+	assert.Error(t, err)
+}
+
+func TestExecCollector_Collect(t *testing.T) {
+	tests := []struct {
+		name    string
+		command ExecCommand
+		want    []metrics.Metrics
+		wantErr bool
+	}{
+		{
+			name: "Line format with env and dir",
+			command: ExecCommand{
+				Name:    "queue",
+				Command: []string{"sh", "-c", `echo "gauge QueueDepth $DEPTH"; test "$(pwd)" = / && echo "gauge InRootDir 1"`},
+				Env:     []string{"DEPTH=17"},
+				Dir:     "/",
+			},
+			want: []metrics.Metrics{
+				*metrics.NewGauge("QueueDepth", 17),
+				*metrics.NewGauge("InRootDir", 1),
+			},
+		},
+		{
+			name: "JSON format",
+			command: ExecCommand{
+				Name:    "json",
+				Command: []string{"echo", `[{"id":"Errors","type":"counter","delta":5}]`},
+				Format:  ExecFormatJSON,
+			},
+			want: []metrics.Metrics{*metrics.NewCounter("Errors", 5)},
+		},
+		{
+			name: "Non-zero exit code",
+			command: ExecCommand{
+				Name:    "failing",
+				Command: []string{"sh", "-c", "echo gauge A 1; echo broken >&2; exit 3"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Timeout",
+			command: ExecCommand{
+				Name:    "slow",
+				Command: []string{"sleep", "5"},
+				Timeout: 50 * time.Millisecond,
+			},
+			wantErr: true,
+		},
+		{
+			name: "Timeout of a forking command",
+			command: ExecCommand{
+				Name:    "forking",
+				Command: []string{"sh", "-c", "sleep 3; echo gauge A 1"},
+				Timeout: 100 * time.Millisecond,
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewExecCollector(tt.command)
+			require.NoError(t, err)
+			assert.Equal(t, "exec:"+tt.command.Name, c.Name())
+
+			started := time.Now()
+			collection, err := c.Collect(context.Background())
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Less(t, time.Since(started), time.Second)
+			assert.Equal(t, tt.want, collection)
+		})
+	}
+}
+
+func TestNewExecCollector_Invalid(t *testing.T) {
+	_, err := NewExecCollector(ExecCommand{Command: []string{"true"}})
+	assert.Error(t, err)
+	_, err = NewExecCollector(ExecCommand{Name: "empty"})
+	assert.Error(t, err)
+	_, err = NewExecCollector(ExecCommand{Name: "xml", Command: []string{"true"}, Format: "xml"})
+	assert.Error(t, err)
+}
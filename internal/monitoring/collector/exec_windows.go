@@ -0,0 +1,12 @@
+package collector
+
+import (
+	"os/exec"
+)
+
+func setProcessGroup(_ *exec.Cmd) {}
+
+// killProcessGroup завершает команду. Её дочерние процессы завершаются сами, когда чтение вывода прервётся
+func killProcessGroup(cmd *exec.Cmd) {
+	_ = cmd.Process.Kill()
+}
@@ -0,0 +1,40 @@
+package collector
+
+import (
+	"fmt"
+	"path"
+)
+
+// Filter отбирает значения по glob-шаблонам (см. path.Match, звёздочка не захватывает "/"). Пустой Include пропускает всё,
+// Exclude имеет приоритет над Include
+type Filter struct {
+	Include []string
+	Exclude []string
+}
+
+// Validate проверяет синтаксис шаблонов
+func (f Filter) Validate() error {
+	for _, pattern := range append(append([]string{}, f.Include...), f.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// Match проверяет, проходит ли значение фильтр
+func (f Filter) Match(value string) bool {
+	if matchAny(f.Exclude, value) {
+		return false
+	}
+	return len(f.Include) == 0 || matchAny(f.Include, value)
+}
+
+func matchAny(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, value); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,34 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilter_Match(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter Filter
+		value  string
+		want   bool
+	}{
+		{name: "Empty filter matches everything", filter: Filter{}, value: "/", want: true},
+		{name: "Included", filter: Filter{Include: []string{"/", "/data*"}}, value: "/data2", want: true},
+		{name: "Not included", filter: Filter{Include: []string{"/", "/data*"}}, value: "/home", want: false},
+		{name: "Excluded", filter: Filter{Exclude: []string{"/snap/*/*"}}, value: "/snap/core/123", want: false},
+		{name: "Star does not cross slashes", filter: Filter{Exclude: []string{"/snap/*"}}, value: "/snap/core/123", want: true},
+		{name: "Exclude wins", filter: Filter{Include: []string{"loop*"}, Exclude: []string{"loop1"}}, value: "loop1", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.filter.Match(tt.value))
+		})
+	}
+}
+
+func TestFilter_Validate(t *testing.T) {
+	assert.NoError(t, Filter{Include: []string{"sd?", "nvme*"}}.Validate())
+	assert.Error(t, Filter{Exclude: []string{"[sd"}}.Validate())
+}
@@ -0,0 +1,294 @@
+package collector
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/metrics"
+)
+
+// LogTailName префикс имён источников, следящих за лог-файлами
+const LogTailName = "log"
+
+// maxLogLineLength ограничивает незавершённую строку, чтобы файл без переводов строк не занял всю память
+const maxLogLineLength = 1 << 20
+
+// LogRule правило подсчёта строк лога. Каждая подходящая строка увеличивает счётчик Counter,
+// а если задана группа Value, её числовое значение попадает в датчик или гистограмму ValueMetric
+type LogRule struct {
+	Counter     string         // Название счётчика подходящих строк
+	Pattern     *regexp.Regexp // Регулярное выражение для строки
+	Value       string         // Имя или номер группы с числовым значением
+	ValueMetric string         // Название метрики со значением группы
+	ValueType   string         // Тип метрики со значением: gauge или histogram
+	Buckets     []float64      // Границы корзин гистограммы, по умолчанию metrics.DefaultBuckets
+
+	group int
+}
+
+// NewLogRule создаёт правило, компилируя регулярное выражение и проверяя группу со значением
+func NewLogRule(counter string, pattern string, value string, valueMetric string, valueType string, buckets []float64) (LogRule, error) {
+	rule := LogRule{
+		Counter:     counter,
+		Value:       value,
+		ValueMetric: valueMetric,
+		ValueType:   valueType,
+		Buckets:     buckets,
+	}
+	if counter == "" {
+		return rule, errors.New("log rule has an empty counter name")
+	}
+
+	var err error
+	if rule.Pattern, err = regexp.Compile(pattern); err != nil {
+		return rule, fmt.Errorf("log rule %q: invalid pattern: %w", counter, err)
+	}
+	if value == "" {
+		return rule, nil
+	}
+
+	rule.group = rule.Pattern.SubexpIndex(value)
+	if rule.group < 0 {
+		if rule.group, err = strconv.Atoi(value); err != nil || rule.group < 1 || rule.group > rule.Pattern.NumSubexp() {
+			return rule, fmt.Errorf("log rule %q: pattern has no group %q", counter, value)
+		}
+	}
+	if valueMetric == "" {
+		return rule, fmt.Errorf("log rule %q: value metric name is required", counter)
+	}
+	switch valueType {
+	case "":
+		rule.ValueType = metrics.StringGaugeType
+	case metrics.StringGaugeType:
+	case metrics.StringHistogramType:
+		if len(rule.Buckets) == 0 {
+			rule.Buckets = metrics.DefaultBuckets
+		}
+		if err = metrics.NewHistogramBuckets(rule.Buckets).Validate(); err != nil {
+			return rule, fmt.Errorf("log rule %q: %w", counter, err)
+		}
+	default:
+		return rule, fmt.Errorf("log rule %q: unsupported value type %q", counter, valueType)
+	}
+
+	return rule, nil
+}
+
+// LogTailCollector следит за лог-файлом и считает строки, подходящие под правила.
+// При первом открытии файл читается с конца, после ротации новый файл читается с начала,
+// а после усечения — с начала того же файла. Если файла не было при первом сборе, то появившийся
+// файл тоже читается с начала. Метрики помечаются меткой log с именем источника
+type LogTailCollector struct {
+	name     string
+	path     string
+	rules    []LogRule
+	interval time.Duration
+
+	mu      sync.Mutex
+	file    *os.File
+	offset  int64
+	partial []byte
+	opened  bool // Файл уже открывался, значит новые файлы после ротации читаются с начала
+}
+
+func NewLogTailCollector(interval time.Duration, name string, path string, rules []LogRule) (*LogTailCollector, error) {
+	if name == "" {
+		return nil, errors.New("log source has an empty name")
+	}
+	if path == "" {
+		return nil, fmt.Errorf("log source %q has an empty path", name)
+	}
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("log source %q has no rules", name)
+	}
+
+	return &LogTailCollector{
+		name:     name,
+		path:     path,
+		rules:    rules,
+		interval: interval,
+	}, nil
+}
+
+func (c *LogTailCollector) Name() string {
+	return LogTailName + ":" + c.name
+}
+
+func (c *LogTailCollector) Interval() time.Duration {
+	return c.interval
+}
+
+func (c *LogTailCollector) Collect(_ context.Context) ([]metrics.Metrics, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// строки сразу применяются к правилам, чтобы не хранить в памяти все строки за период
+	match := newLogMatch(c.name, c.rules)
+	err := c.follow(match.add)
+
+	return match.metrics(), err
+}
+
+// follow дочитывает новые строки текущего файла, а затем переключается на новый файл, если лог ротировали
+func (c *LogTailCollector) follow(emit func(line []byte)) error {
+	if c.file == nil {
+		if err := c.open(); err != nil {
+			return err
+		}
+	}
+
+	if info, err := c.file.Stat(); err == nil && info.Size() < c.offset {
+		// файл усекли, читаем его заново
+		c.offset = 0
+		c.partial = nil
+	}
+	if err := c.read(emit); err != nil {
+		return err
+	}
+
+	current, err := c.file.Stat()
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", c.path, err)
+	}
+	latest, err := os.Stat(c.path)
+	if err != nil || os.SameFile(current, latest) {
+		// пока новый файл не создан после ротации, продолжаем следить за старым
+		return nil
+	}
+
+	c.file.Close()
+	c.file = nil
+	if c.partial != nil {
+		emit(c.partial)
+		c.partial = nil
+	}
+	if err = c.open(); err != nil {
+		return err
+	}
+	return c.read(emit)
+}
+
+func (c *LogTailCollector) open() error {
+	file, err := os.Open(c.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			// файл ещё не создан, поэтому всё, что в него запишут, новое
+			c.opened = true
+		}
+		return fmt.Errorf("opening %s: %w", c.path, err)
+	}
+
+	c.offset = 0
+	if !c.opened {
+		if c.offset, err = file.Seek(0, io.SeekEnd); err != nil {
+			file.Close()
+			return fmt.Errorf("opening %s: %w", c.path, err)
+		}
+	}
+	c.file = file
+	c.opened = true
+	return nil
+}
+
+// read читает файл до конца и передаёт завершённые строки, незавершённая остаётся до следующего чтения
+func (c *LogTailCollector) read(emit func(line []byte)) error {
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := c.file.ReadAt(buf, c.offset)
+		c.offset += int64(n)
+
+		data := buf[:n]
+		for {
+			i := bytes.IndexByte(data, '\n')
+			if i < 0 {
+				break
+			}
+			emit(append(c.partial, bytes.TrimSuffix(data[:i], []byte("\r"))...))
+			c.partial = nil
+			data = data[i+1:]
+		}
+		if len(data) > 0 && len(c.partial) < maxLogLineLength {
+			c.partial = append(c.partial, data...)
+		}
+
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", c.path, err)
+		}
+	}
+}
+
+// logMatch применяет правила к строкам по мере чтения. Счётчики отправляются всегда,
+// а датчики и гистограммы — только если за период нашлись значения
+type logMatch struct {
+	rules      []LogRule
+	labels     map[string]string
+	counts     []int64
+	gauges     []*metrics.Metrics
+	histograms []*metrics.Metrics
+}
+
+func newLogMatch(name string, rules []LogRule) *logMatch {
+	m := &logMatch{
+		rules:      rules,
+		labels:     map[string]string{"log": name},
+		counts:     make([]int64, len(rules)),
+		gauges:     make([]*metrics.Metrics, len(rules)),
+		histograms: make([]*metrics.Metrics, len(rules)),
+	}
+	for i, rule := range rules {
+		if rule.ValueType == metrics.StringHistogramType {
+			m.histograms[i] = metrics.NewHistogram(rule.ValueMetric, rule.Buckets).WithLabels(m.labels)
+		}
+	}
+
+	return m
+}
+
+func (m *logMatch) add(line []byte) {
+	for i, rule := range m.rules {
+		groups := rule.Pattern.FindSubmatch(line)
+		if groups == nil {
+			continue
+		}
+		m.counts[i]++
+
+		if rule.group == 0 {
+			continue
+		}
+		value, err := strconv.ParseFloat(string(groups[rule.group]), 64)
+		if err != nil {
+			continue
+		}
+		if m.histograms[i] != nil {
+			m.histograms[i].Histogram.Observe(value)
+		} else {
+			m.gauges[i] = metrics.NewGauge(rule.ValueMetric, value).WithLabels(m.labels)
+		}
+	}
+}
+
+func (m *logMatch) metrics() []metrics.Metrics {
+	var collection []metrics.Metrics
+	for i, rule := range m.rules {
+		collection = append(collection, *metrics.NewCounter(rule.Counter, m.counts[i]).WithLabels(m.labels))
+		if m.gauges[i] != nil {
+			collection = append(collection, *m.gauges[i])
+		}
+		if m.histograms[i] != nil && m.histograms[i].Histogram.Count > 0 {
+			collection = append(collection, *m.histograms[i])
+		}
+	}
+
+	return collection
+}
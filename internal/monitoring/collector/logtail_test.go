@@ -0,0 +1,140 @@
+package collector
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/metrics"
+)
+
+func TestNewLogRule(t *testing.T) {
+	tests := []struct {
+		name        string
+		pattern     string
+		value       string
+		valueMetric string
+		valueType   string
+		buckets     []float64
+		wantErr     bool
+	}{
+		{name: "Counter only", pattern: "ERROR"},
+		{name: "Named group", pattern: `took (?P<ms>\d+)ms`, value: "ms", valueMetric: "RequestMs"},
+		{name: "Numbered group histogram", pattern: `took (\d+)ms`, value: "1", valueMetric: "RequestMs", valueType: "histogram"},
+		{name: "Invalid pattern", pattern: "(", wantErr: true},
+		{name: "Unknown group", pattern: `took (\d+)ms`, value: "2", valueMetric: "RequestMs", wantErr: true},
+		{name: "No value metric", pattern: `took (\d+)ms`, value: "1", wantErr: true},
+		{name: "Unsupported value type", pattern: `took (\d+)ms`, value: "1", valueMetric: "RequestMs", valueType: "counter", wantErr: true},
+		{name: "Invalid buckets", pattern: `took (\d+)ms`, value: "1", valueMetric: "RequestMs", valueType: "histogram", buckets: []float64{1, 1}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewLogRule("Matches", tt.pattern, tt.value, tt.valueMetric, tt.valueType, tt.buckets)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestLogTailCollector_Collect(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	write := func(content string) {
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+		require.NoError(t, err)
+		_, err = file.WriteString(content)
+		require.NoError(t, err)
+		require.NoError(t, file.Close())
+	}
+	write("ERROR old line before the agent started\n")
+
+	errorRule, err := NewLogRule("LogErrors", "ERROR", "", "", "", nil)
+	require.NoError(t, err)
+	requests, err := NewLogRule("LogRequests", `took (?P<ms>[\d.]+)ms`, "ms", "RequestMs", "gauge", nil)
+	require.NoError(t, err)
+	c, err := NewLogTailCollector(0, "app", path, []LogRule{errorRule, requests})
+	require.NoError(t, err)
+	assert.Equal(t, "log:app", c.Name())
+
+	labels := map[string]string{"log": "app"}
+	collect := func() []metrics.Metrics {
+		collection, err := c.Collect(context.Background())
+		require.NoError(t, err)
+		return collection
+	}
+
+	assert.Equal(t, []metrics.Metrics{
+		*metrics.NewCounter("LogErrors", 0).WithLabels(labels),
+		*metrics.NewCounter("LogRequests", 0).WithLabels(labels),
+	}, collect(), "existing lines are skipped")
+
+	write("ERROR db is down\nGET / took 12ms\nGET / took 7.5ms\nERROR unfinished")
+	assert.Equal(t, []metrics.Metrics{
+		*metrics.NewCounter("LogErrors", 1).WithLabels(labels),
+		*metrics.NewCounter("LogRequests", 2).WithLabels(labels),
+		*metrics.NewGauge("RequestMs", 7.5).WithLabels(labels),
+	}, collect(), "an unfinished line waits for its end")
+
+	write(" line\n")
+	require.NoError(t, os.Rename(path, path+".1"))
+	write("ERROR in the new file\n")
+	assert.Equal(t, []metrics.Metrics{
+		*metrics.NewCounter("LogErrors", 2).WithLabels(labels),
+		*metrics.NewCounter("LogRequests", 0).WithLabels(labels),
+	}, collect(), "the rotated file is drained and the new one is read from the start")
+
+	require.NoError(t, os.Truncate(path, 0))
+	write("GET / took 3ms\n")
+	assert.Equal(t, []metrics.Metrics{
+		*metrics.NewCounter("LogErrors", 0).WithLabels(labels),
+		*metrics.NewCounter("LogRequests", 1).WithLabels(labels),
+		*metrics.NewGauge("RequestMs", 3).WithLabels(labels),
+	}, collect(), "a truncated file is read from the start")
+}
+
+func TestLogTailCollector_FileCreatedLater(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	rule, err := NewLogRule("LogErrors", "ERROR", "", "", "", nil)
+	require.NoError(t, err)
+	c, err := NewLogTailCollector(0, "app", path, []LogRule{rule})
+	require.NoError(t, err)
+
+	_, err = c.Collect(context.Background())
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	require.NoError(t, os.WriteFile(path, []byte("ERROR first\nERROR second\n"), 0o644))
+	collection, err := c.Collect(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []metrics.Metrics{
+		*metrics.NewCounter("LogErrors", 2).WithLabels(map[string]string{"log": "app"}),
+	}, collection, "lines written before the file was first opened are counted")
+}
+
+func TestLogTailCollector_Histogram(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	require.NoError(t, os.WriteFile(path, nil, 0o644))
+
+	rule, err := NewLogRule("LogRequests", `took ([\d.]+)s`, "1", "RequestSeconds", "histogram", []float64{0.1, 1})
+	require.NoError(t, err)
+	c, err := NewLogTailCollector(0, "app", path, []LogRule{rule})
+	require.NoError(t, err)
+	_, err = c.Collect(context.Background())
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(path, []byte("took 0.05s\ntook 0.5s\ntook 3s\n"), 0o644))
+	collection, err := c.Collect(context.Background())
+	require.NoError(t, err)
+	require.Len(t, collection, 2)
+	assert.Equal(t, &metrics.Histogram{
+		Bounds: []float64{0.1, 1},
+		Counts: []uint64{1, 1, 1},
+		Count:  3,
+		Sum:    3.55,
+	}, collection[1].Histogram)
+}
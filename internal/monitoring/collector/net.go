@@ -0,0 +1,157 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/net"
+
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/metrics"
+)
+
+// NetName имя источника сетевых метрик
+const NetName = "net"
+
+// tcpStates состояния TCP-соединений, которые отправляются всегда, даже если таких соединений нет,
+// чтобы значение обнулялось, а не оставалось прежним
+var tcpStates = []string{
+	"ESTABLISHED", "SYN_SENT", "SYN_RECV", "FIN_WAIT1", "FIN_WAIT2", "TIME_WAIT",
+	"CLOSE", "CLOSE_WAIT", "LAST_ACK", "LISTEN", "CLOSING",
+}
+
+// NetCollector собирает по каждому сетевому интерфейсу прирост отправленных и полученных байт, пакетов,
+// ошибок и отброшенных пакетов с меткой interface, а также количество TCP-соединений в каждом состоянии
+type NetCollector struct {
+	interval    time.Duration
+	interfaces  Filter
+	ioCounters  func(ctx context.Context, perNIC bool) ([]net.IOCountersStat, error)
+	connections func(ctx context.Context, kind string) ([]net.ConnectionStat, error)
+
+	mu   sync.Mutex
+	prev map[string]net.IOCountersStat
+}
+
+func NewNetCollector(interval time.Duration, interfaces Filter) (*NetCollector, error) {
+	if err := interfaces.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &NetCollector{
+		interval:    interval,
+		interfaces:  interfaces,
+		ioCounters:  net.IOCountersWithContext,
+		connections: net.ConnectionsWithoutUidsWithContext,
+	}, nil
+}
+
+func (c *NetCollector) Name() string {
+	return NetName
+}
+
+func (c *NetCollector) Interval() time.Duration {
+	return c.interval
+}
+
+func (c *NetCollector) Collect(ctx context.Context) ([]metrics.Metrics, error) {
+	var collection []metrics.Metrics
+	var errs []error
+
+	io, err := c.collectIO(ctx)
+	collection = append(collection, io...)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	tcp, err := c.collectTCP(ctx)
+	collection = append(collection, tcp...)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	return collection, joinErrors(errs)
+}
+
+// collectIO возвращает прирост счётчиков интерфейсов с предыдущего сбора. Новый интерфейс служит
+// точкой отсчёта, а сбросившийся счётчик пропускается до следующего сбора
+func (c *NetCollector) collectIO(ctx context.Context) ([]metrics.Metrics, error) {
+	counters, err := c.ioCounters(ctx, true)
+	if err != nil {
+		return nil, fmt.Errorf("reading network IO counters: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	prev := c.prev
+	c.prev = make(map[string]net.IOCountersStat, len(counters))
+
+	var collection []metrics.Metrics
+	for _, cur := range counters {
+		if !c.interfaces.Match(cur.Name) {
+			continue
+		}
+		c.prev[cur.Name] = cur
+
+		old, ok := prev[cur.Name]
+		if !ok {
+			continue
+		}
+
+		labels := map[string]string{"interface": cur.Name}
+		for _, counter := range []struct {
+			id       string
+			cur, old uint64
+		}{
+			{id: "NetBytesSent", cur: cur.BytesSent, old: old.BytesSent},
+			{id: "NetBytesRecv", cur: cur.BytesRecv, old: old.BytesRecv},
+			{id: "NetPacketsSent", cur: cur.PacketsSent, old: old.PacketsSent},
+			{id: "NetPacketsRecv", cur: cur.PacketsRecv, old: old.PacketsRecv},
+			{id: "NetErrorsIn", cur: cur.Errin, old: old.Errin},
+			{id: "NetErrorsOut", cur: cur.Errout, old: old.Errout},
+			{id: "NetDropsIn", cur: cur.Dropin, old: old.Dropin},
+			{id: "NetDropsOut", cur: cur.Dropout, old: old.Dropout},
+		} {
+			if counter.cur < counter.old {
+				continue
+			}
+			collection = append(collection, *metrics.NewCounter(counter.id, int64(counter.cur-counter.old)).WithLabels(labels))
+		}
+	}
+
+	return collection, nil
+}
+
+func (c *NetCollector) collectTCP(ctx context.Context) ([]metrics.Metrics, error) {
+	connections, err := c.connections(ctx, "tcp")
+	if err != nil {
+		return nil, fmt.Errorf("reading TCP connections: %w", err)
+	}
+
+	counts := make(map[string]int, len(tcpStates))
+	for _, state := range tcpStates {
+		counts[state] = 0
+	}
+	for _, connection := range connections {
+		if connection.Status == "" || connection.Status == "NONE" {
+			continue
+		}
+		counts[connection.Status]++
+	}
+
+	states := make([]string, 0, len(counts))
+	for state := range counts {
+		states = append(states, state)
+	}
+	sort.Strings(states)
+
+	collection := make([]metrics.Metrics, 0, len(states))
+	for _, state := range states {
+		collection = append(collection,
+			*metrics.NewGauge("NetTCPConnections", float64(counts[state])).WithLabels(map[string]string{"state": state}))
+	}
+
+	return collection, nil
+}
@@ -0,0 +1,67 @@
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/shirou/gopsutil/net"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/metrics"
+)
+
+func TestNetCollector_Collect(t *testing.T) {
+	c, err := NewNetCollector(0, Filter{Exclude: []string{"lo", "veth*"}})
+	require.NoError(t, err)
+
+	samples := [][]net.IOCountersStat{
+		{
+			{Name: "eth0", BytesSent: 1000, BytesRecv: 5000, PacketsSent: 10, PacketsRecv: 50, Errin: 1},
+			{Name: "lo", BytesSent: 100, BytesRecv: 100},
+			{Name: "veth1a2b", BytesSent: 7},
+		},
+		{
+			{Name: "eth0", BytesSent: 1500, BytesRecv: 9000, PacketsSent: 15, PacketsRecv: 90, Errin: 3, Dropin: 2},
+			{Name: "lo", BytesSent: 200, BytesRecv: 200},
+			{Name: "eth1", BytesSent: 42},
+		},
+	}
+	call := 0
+	c.ioCounters = func(_ context.Context, _ bool) ([]net.IOCountersStat, error) {
+		defer func() { call++ }()
+		return samples[call], nil
+	}
+	c.connections = func(_ context.Context, kind string) ([]net.ConnectionStat, error) {
+		assert.Equal(t, "tcp", kind)
+		return []net.ConnectionStat{
+			{Status: "ESTABLISHED"}, {Status: "ESTABLISHED"}, {Status: "LISTEN"}, {Status: "TIME_WAIT"}, {Status: "NONE"},
+		}, nil
+	}
+
+	first, err := c.Collect(context.Background())
+	require.NoError(t, err)
+	for _, m := range first {
+		assert.Equal(t, "NetTCPConnections", m.ID, "the first collection must not report IO deltas")
+	}
+
+	second, err := c.Collect(context.Background())
+	require.NoError(t, err)
+	byKey := make(map[string]metrics.Metrics, len(second))
+	for _, m := range second {
+		byKey[m.Key()] = m
+	}
+
+	assert.Equal(t, int64(500), *byKey[`NetBytesSent{interface="eth0"}`].Delta)
+	assert.Equal(t, int64(4000), *byKey[`NetBytesRecv{interface="eth0"}`].Delta)
+	assert.Equal(t, int64(40), *byKey[`NetPacketsRecv{interface="eth0"}`].Delta)
+	assert.Equal(t, int64(2), *byKey[`NetErrorsIn{interface="eth0"}`].Delta)
+	assert.Equal(t, int64(2), *byKey[`NetDropsIn{interface="eth0"}`].Delta)
+	assert.NotContains(t, byKey, `NetBytesSent{interface="lo"}`)
+	assert.NotContains(t, byKey, `NetBytesSent{interface="eth1"}`, "a new interface is only a baseline")
+
+	assert.Equal(t, float64(2), *byKey[`NetTCPConnections{state="ESTABLISHED"}`].Value)
+	assert.Equal(t, float64(1), *byKey[`NetTCPConnections{state="LISTEN"}`].Value)
+	assert.Equal(t, float64(0), *byKey[`NetTCPConnections{state="CLOSE_WAIT"}`].Value)
+	assert.NotContains(t, byKey, `NetTCPConnections{state="NONE"}`)
+}
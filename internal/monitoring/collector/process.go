@@ -0,0 +1,328 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/process"
+
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/metrics"
+)
+
+// ProcessName имя источника метрик процессов
+const ProcessName = "process"
+
+// ProcessRule правило отбора процессов. Заданные условия должны выполняться одновременно
+type ProcessRule struct {
+	Name    string         // Значение метки process у найденных процессов
+	Pattern *regexp.Regexp // Регулярное выражение для имени процесса
+	Cmdline *regexp.Regexp // Регулярное выражение для командной строки
+	PidFile string         // Файл с PID процесса
+	PerPid  bool           // Собирать метрики каждого процесса отдельно с меткой pid, а не сумму по правилу
+}
+
+// NewProcessRule создаёт правило, компилируя регулярные выражения. Пустые условия не проверяются
+func NewProcessRule(name string, pattern string, cmdline string, pidFile string) (ProcessRule, error) {
+	rule := ProcessRule{Name: name, PidFile: pidFile}
+	if name == "" {
+		return rule, errors.New("process rule has an empty name")
+	}
+	if pattern == "" && cmdline == "" && pidFile == "" {
+		return rule, fmt.Errorf("process rule %q: one of pattern, cmdline or pidfile is required", name)
+	}
+
+	var err error
+	if pattern != "" {
+		if rule.Pattern, err = regexp.Compile(pattern); err != nil {
+			return rule, fmt.Errorf("process rule %q: invalid pattern: %w", name, err)
+		}
+	}
+	if cmdline != "" {
+		if rule.Cmdline, err = regexp.Compile(cmdline); err != nil {
+			return rule, fmt.Errorf("process rule %q: invalid cmdline pattern: %w", name, err)
+		}
+	}
+
+	return rule, nil
+}
+
+type processInfo struct {
+	Pid     int32
+	Name    string
+	Cmdline string
+}
+
+type processUsage struct {
+	CPUSeconds float64
+	RSS        uint64
+	FDs        int32
+	Threads    int32
+	CreateTime time.Time
+}
+
+type processCPU struct {
+	seconds float64
+	at      time.Time
+	created time.Time // Время запуска отличает процесс от нового процесса с тем же PID
+}
+
+// ProcessCollector собирает по процессам, подходящим под правило, загрузку процессора в процентах,
+// резидентную память, количество открытых дескрипторов, потоков и время работы самого старого процесса.
+// Метрики помечаются меткой process (имя правила) и суммируются по всем процессам правила, чтобы количество
+// метрик не росло с каждым перезапуском. Если правилу не подошёл ни один процесс, суммы равны нулю.
+// Правила с PerPid собирают метрики каждого процесса с меткой pid. Загрузка процессора считается
+// с предыдущего сбора, поэтому процесс, найденный впервые, в неё не входит.
+// ProcessCount показывает, сколько процессов найдено
+type ProcessCollector struct {
+	interval time.Duration
+	rules    []ProcessRule
+	list     func(ctx context.Context) ([]processInfo, error)
+	usage    func(ctx context.Context, pid int32) (processUsage, error)
+	readFile func(name string) ([]byte, error)
+	now      func() time.Time
+
+	mu   sync.Mutex
+	prev map[int32]processCPU
+}
+
+func NewProcessCollector(interval time.Duration, rules []ProcessRule) (*ProcessCollector, error) {
+	names := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		if names[rule.Name] {
+			return nil, fmt.Errorf("duplicate process rule name %q", rule.Name)
+		}
+		names[rule.Name] = true
+	}
+
+	return &ProcessCollector{
+		interval: interval,
+		rules:    rules,
+		list:     listProcesses,
+		usage:    readProcessUsage,
+		readFile: os.ReadFile,
+		now:      time.Now,
+	}, nil
+}
+
+func (c *ProcessCollector) Name() string {
+	return ProcessName
+}
+
+func (c *ProcessCollector) Interval() time.Duration {
+	return c.interval
+}
+
+func (c *ProcessCollector) Collect(ctx context.Context) ([]metrics.Metrics, error) {
+	if len(c.rules) == 0 {
+		return nil, nil
+	}
+
+	// правилам только с pidfile список процессов не нужен, а получение имён и командных строк
+	// всех процессов системы обходится дорого
+	var processes []processInfo
+	for _, rule := range c.rules {
+		if rule.Pattern == nil && rule.Cmdline == nil {
+			continue
+		}
+		var err error
+		if processes, err = c.list(ctx); err != nil {
+			return nil, fmt.Errorf("listing processes: %w", err)
+		}
+		break
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	prev := c.prev
+	c.prev = make(map[int32]processCPU)
+
+	var collection []metrics.Metrics
+	var errs []error
+	for _, rule := range c.rules {
+		matched, err := c.match(rule, processes)
+		if err != nil {
+			errs = append(errs, err)
+		}
+
+		var total processStats
+		count := 0
+		for _, info := range matched {
+			usage, err := c.usage(ctx, info.Pid)
+			if err != nil {
+				// процесс мог завершиться между получением списка и чтением статистики
+				continue
+			}
+			count++
+
+			cpu := processCPU{seconds: usage.CPUSeconds, at: now, created: usage.CreateTime}
+			c.prev[info.Pid] = cpu
+			stats := processStats{
+				RSS:     float64(usage.RSS),
+				FDs:     float64(usage.FDs),
+				Threads: float64(usage.Threads),
+				Uptime:  now.Sub(usage.CreateTime).Seconds(),
+			}
+			// без предыдущего сбора получилась бы средняя загрузка за всё время работы процесса
+			old, ok := prev[info.Pid]
+			if ok && old.created.Equal(cpu.created) && old.seconds <= cpu.seconds {
+				if elapsed := now.Sub(old.at).Seconds(); elapsed > 0 {
+					stats.CPUPercent = (cpu.seconds - old.seconds) / elapsed * 100
+					stats.hasCPU = true
+				}
+			}
+
+			if rule.PerPid {
+				collection = append(collection, stats.metrics(map[string]string{
+					"process": rule.Name,
+					"pid":     strconv.Itoa(int(info.Pid)),
+				})...)
+			}
+			total.add(stats)
+		}
+
+		labels := map[string]string{"process": rule.Name}
+		if !rule.PerPid {
+			if count == 0 {
+				// нулевые суммы заменяют на сервере значения последних найденных процессов
+				total.hasCPU = true
+			}
+			collection = append(collection, total.metrics(labels)...)
+		}
+		collection = append(collection, *metrics.NewGauge("ProcessCount", float64(count)).WithLabels(labels))
+	}
+
+	return collection, joinErrors(errs)
+}
+
+// processStats метрики одного процесса или сумма по процессам правила
+type processStats struct {
+	CPUPercent float64
+	RSS        float64
+	FDs        float64
+	Threads    float64
+	Uptime     float64 // Для суммы время работы самого старого процесса
+	hasCPU     bool    // Загрузка процессора измерена хотя бы у одного процесса
+}
+
+func (s *processStats) add(other processStats) {
+	if other.hasCPU {
+		s.CPUPercent += other.CPUPercent
+		s.hasCPU = true
+	}
+	s.RSS += other.RSS
+	s.FDs += other.FDs
+	s.Threads += other.Threads
+	s.Uptime = math.Max(s.Uptime, other.Uptime)
+}
+
+func (s processStats) metrics(labels map[string]string) []metrics.Metrics {
+	var collection []metrics.Metrics
+	if s.hasCPU {
+		collection = append(collection, *metrics.NewGauge("ProcessCPUPercent", s.CPUPercent).WithLabels(labels))
+	}
+	return append(collection,
+		*metrics.NewGauge("ProcessRSSBytes", s.RSS).WithLabels(labels),
+		*metrics.NewGauge("ProcessOpenFDs", s.FDs).WithLabels(labels),
+		*metrics.NewGauge("ProcessThreads", s.Threads).WithLabels(labels),
+		*metrics.NewGauge("ProcessUptimeSeconds", s.Uptime).WithLabels(labels),
+	)
+}
+
+func (c *ProcessCollector) match(rule ProcessRule, processes []processInfo) ([]processInfo, error) {
+	pid := int32(-1)
+	if rule.PidFile != "" {
+		content, err := c.readFile(rule.PidFile)
+		if err != nil {
+			return nil, fmt.Errorf("process rule %q: reading pidfile: %w", rule.Name, err)
+		}
+		parsed, err := strconv.ParseInt(strings.TrimSpace(string(content)), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("process rule %q: invalid pidfile content: %w", rule.Name, err)
+		}
+		pid = int32(parsed)
+
+		if rule.Pattern == nil && rule.Cmdline == nil {
+			// существование процесса проверится при чтении его статистики
+			return []processInfo{{Pid: pid}}, nil
+		}
+	}
+
+	var matched []processInfo
+	for _, info := range processes {
+		if pid >= 0 && info.Pid != pid {
+			continue
+		}
+		if rule.Pattern != nil && !rule.Pattern.MatchString(info.Name) {
+			continue
+		}
+		if rule.Cmdline != nil && !rule.Cmdline.MatchString(info.Cmdline) {
+			continue
+		}
+		matched = append(matched, info)
+	}
+
+	return matched, nil
+}
+
+func listProcesses(ctx context.Context) ([]processInfo, error) {
+	processes, err := process.ProcessesWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]processInfo, 0, len(processes))
+	for _, p := range processes {
+		name, err := p.NameWithContext(ctx)
+		if err != nil {
+			continue
+		}
+		cmdline, _ := p.CmdlineWithContext(ctx)
+		infos = append(infos, processInfo{Pid: p.Pid, Name: name, Cmdline: cmdline})
+	}
+
+	return infos, nil
+}
+
+func readProcessUsage(ctx context.Context, pid int32) (processUsage, error) {
+	p, err := process.NewProcessWithContext(ctx, pid)
+	if err != nil {
+		return processUsage{}, err
+	}
+
+	times, err := p.TimesWithContext(ctx)
+	if err != nil {
+		return processUsage{}, err
+	}
+	memory, err := p.MemoryInfoWithContext(ctx)
+	if err != nil {
+		return processUsage{}, err
+	}
+	createTime, err := p.CreateTimeWithContext(ctx)
+	if err != nil {
+		return processUsage{}, err
+	}
+	threads, err := p.NumThreadsWithContext(ctx)
+	if err != nil {
+		return processUsage{}, err
+	}
+	// дескрипторы чужих процессов могут быть недоступны без прав, это не повод пропускать процесс
+	fds, _ := p.NumFDsWithContext(ctx)
+
+	return processUsage{
+		CPUSeconds: times.User + times.System,
+		RSS:        memory.RSS,
+		FDs:        fds,
+		Threads:    threads,
+		CreateTime: time.Unix(0, createTime*int64(time.Millisecond)),
+	}, nil
+}
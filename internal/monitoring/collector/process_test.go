@@ -0,0 +1,217 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/metrics"
+)
+
+func TestProcessCollector_Collect(t *testing.T) {
+	byName, err := NewProcessRule("postgres", "^postgres$", "", "")
+	require.NoError(t, err)
+	byCmdline, err := NewProcessRule("server", "", `cmd/server\b`, "")
+	require.NoError(t, err)
+	byPidFile, err := NewProcessRule("nginx", "", "", "/run/nginx.pid")
+	require.NoError(t, err)
+	byName.PerPid = true
+	missing, err := NewProcessRule("redis", "^redis-server$", "", "")
+	require.NoError(t, err)
+	byPidFileAndName, err := NewProcessRule("nginx-master", "^nginx$", "", "/run/nginx.pid")
+	require.NoError(t, err)
+
+	c, err := NewProcessCollector(0, []ProcessRule{byName, byCmdline, byPidFile, missing, byPidFileAndName})
+	require.NoError(t, err)
+
+	start := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	now := start.Add(100 * time.Second)
+	cpuSeconds := map[int32]float64{10: 50, 11: 1, 20: 5, 30: 2}
+	c.now = func() time.Time { return now }
+	c.list = func(_ context.Context) ([]processInfo, error) {
+		return []processInfo{
+			{Pid: 10, Name: "postgres", Cmdline: "/usr/bin/postgres -D /data"},
+			{Pid: 11, Name: "postgres", Cmdline: "postgres: checkpointer"},
+			{Pid: 12, Name: "postgres_exporter", Cmdline: "postgres_exporter"},
+			{Pid: 20, Name: "main", Cmdline: "/tmp/go-build/main cmd/server -a :8080"},
+			{Pid: 30, Name: "nginx", Cmdline: "nginx: master process"},
+			{Pid: 31, Name: "nginx", Cmdline: "nginx: worker process"},
+		}, nil
+	}
+	c.usage = func(_ context.Context, pid int32) (processUsage, error) {
+		if pid == 11 && now.After(start.Add(100*time.Second)) {
+			return processUsage{}, errors.New("process exited")
+		}
+		return processUsage{CPUSeconds: cpuSeconds[pid], RSS: 1 << 20, FDs: 12, Threads: 4, CreateTime: start}, nil
+	}
+	c.readFile = func(name string) ([]byte, error) {
+		if name != "/run/nginx.pid" {
+			return nil, os.ErrNotExist
+		}
+		return []byte("30\n"), nil
+	}
+
+	first, err := c.Collect(context.Background())
+	require.NoError(t, err)
+	byKey := make(map[string]metrics.Metrics, len(first))
+	for _, m := range first {
+		byKey[m.Key()] = m
+	}
+
+	assert.Equal(t, float64(2), *byKey[`ProcessCount{process="postgres"}`].Value)
+	assert.Equal(t, float64(1), *byKey[`ProcessCount{process="server"}`].Value)
+	assert.Equal(t, float64(1), *byKey[`ProcessCount{process="nginx"}`].Value)
+	assert.Equal(t, float64(0), *byKey[`ProcessCount{process="redis"}`].Value)
+	assert.Equal(t, float64(1), *byKey[`ProcessCount{process="nginx-master"}`].Value)
+	assert.NotContains(t, byKey, `ProcessCPUPercent{pid="10",process="postgres"}`, "no usage without a previous collection")
+	assert.NotContains(t, byKey, `ProcessRSSBytes{process="postgres"}`, "per-pid rules are not summed")
+	assert.Equal(t, float64(1<<20), *byKey[`ProcessRSSBytes{process="server"}`].Value)
+	assert.Equal(t, float64(12), *byKey[`ProcessOpenFDs{process="nginx"}`].Value)
+	assert.Equal(t, float64(4), *byKey[`ProcessThreads{process="nginx"}`].Value)
+	assert.Equal(t, float64(100), *byKey[`ProcessUptimeSeconds{process="nginx"}`].Value)
+	assert.NotContains(t, byKey, `ProcessRSSBytes{pid="12",process="postgres"}`)
+	assert.Equal(t, float64(0), *byKey[`ProcessRSSBytes{process="redis"}`].Value, "sums are reset when nothing matches")
+	assert.Equal(t, float64(0), *byKey[`ProcessCPUPercent{process="redis"}`].Value)
+
+	now = now.Add(10 * time.Second)
+	cpuSeconds[10] += 2.5
+	second, err := c.Collect(context.Background())
+	require.NoError(t, err)
+	byKey = make(map[string]metrics.Metrics, len(second))
+	for _, m := range second {
+		byKey[m.Key()] = m
+	}
+
+	assert.InDelta(t, 25, *byKey[`ProcessCPUPercent{pid="10",process="postgres"}`].Value, 1e-9, "usage since the previous collection")
+	assert.Equal(t, float64(1), *byKey[`ProcessCount{process="postgres"}`].Value, "exited processes must not be counted")
+	assert.InDelta(t, 0, *byKey[`ProcessCPUPercent{process="server"}`].Value, 1e-9)
+}
+
+func TestProcessCollector_RestartedProcessHasNoUsage(t *testing.T) {
+	rule, err := NewProcessRule("server", "^server$", "", "")
+	require.NoError(t, err)
+	c, err := NewProcessCollector(0, []ProcessRule{rule})
+	require.NoError(t, err)
+
+	start := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	now := start.Add(100 * time.Second)
+	createTime := start
+	c.now = func() time.Time { return now }
+	c.list = func(_ context.Context) ([]processInfo, error) {
+		return []processInfo{{Pid: 10, Name: "server"}}, nil
+	}
+	c.usage = func(_ context.Context, _ int32) (processUsage, error) {
+		return processUsage{CPUSeconds: 50, CreateTime: createTime}, nil
+	}
+
+	_, err = c.Collect(context.Background())
+	require.NoError(t, err)
+
+	// процесс перезапустился с тем же PID
+	now = now.Add(10 * time.Second)
+	createTime = now.Add(-time.Second)
+	collection, err := c.Collect(context.Background())
+	require.NoError(t, err)
+	for _, m := range collection {
+		assert.NotEqual(t, "ProcessCPUPercent", m.ID, "usage of a new process is measured from the next collection")
+	}
+}
+
+func TestProcessCollector_PidFileError(t *testing.T) {
+	rule, err := NewProcessRule("nginx", "", "", "/run/nginx.pid")
+	require.NoError(t, err)
+	c, err := NewProcessCollector(0, []ProcessRule{rule})
+	require.NoError(t, err)
+	c.list = func(_ context.Context) ([]processInfo, error) {
+		return []processInfo{{Pid: 1, Name: "init"}}, nil
+	}
+	c.readFile = func(_ string) ([]byte, error) {
+		return []byte("not a pid"), nil
+	}
+
+	collection, err := c.Collect(context.Background())
+	assert.Error(t, err)
+	require.Len(t, collection, 6)
+	assert.Equal(t, "ProcessCount", collection[5].ID)
+	assert.Equal(t, float64(0), *collection[5].Value)
+}
+
+func TestNewProcessRule(t *testing.T) {
+	_, err := NewProcessRule("", "^nginx$", "", "")
+	assert.Error(t, err)
+	_, err = NewProcessRule("nginx", "", "", "")
+	assert.Error(t, err)
+	_, err = NewProcessRule("nginx", "(", "", "")
+	assert.Error(t, err)
+
+	rule, err := NewProcessRule("nginx", "^nginx$", "", "")
+	require.NoError(t, err)
+	_, err = NewProcessCollector(0, []ProcessRule{rule, rule})
+	assert.Error(t, err, "rule names must be unique")
+}
+
+func TestProcessCollector_SumsProcessesOfRule(t *testing.T) {
+	rule, err := NewProcessRule("postgres", "^postgres$", "", "")
+	require.NoError(t, err)
+	c, err := NewProcessCollector(0, []ProcessRule{rule})
+	require.NoError(t, err)
+
+	start := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	now := start.Add(90 * time.Second)
+	cpuSeconds := map[int32]float64{10: 10, 11: 10}
+	c.now = func() time.Time { return now }
+	c.list = func(_ context.Context) ([]processInfo, error) {
+		return []processInfo{{Pid: 10, Name: "postgres"}, {Pid: 11, Name: "postgres"}}, nil
+	}
+	c.usage = func(_ context.Context, pid int32) (processUsage, error) {
+		return processUsage{CPUSeconds: cpuSeconds[pid], RSS: 1 << 20, FDs: 5, Threads: 2, CreateTime: start.Add(time.Duration(pid-10) * 50 * time.Second)}, nil
+	}
+
+	_, err = c.Collect(context.Background())
+	require.NoError(t, err)
+	now = now.Add(10 * time.Second)
+	cpuSeconds[10] += 1
+	cpuSeconds[11] += 2
+	collection, err := c.Collect(context.Background())
+	require.NoError(t, err)
+	labels := map[string]string{"process": "postgres"}
+	assert.Equal(t, []metrics.Metrics{
+		*metrics.NewGauge("ProcessCPUPercent", 30).WithLabels(labels),
+		*metrics.NewGauge("ProcessRSSBytes", 2<<20).WithLabels(labels),
+		*metrics.NewGauge("ProcessOpenFDs", 10).WithLabels(labels),
+		*metrics.NewGauge("ProcessThreads", 4).WithLabels(labels),
+		*metrics.NewGauge("ProcessUptimeSeconds", 100).WithLabels(labels),
+		*metrics.NewGauge("ProcessCount", 2).WithLabels(labels),
+	}, collection)
+}
+
+func TestProcessCollector_PidFileOnlyDoesNotListProcesses(t *testing.T) {
+	rule, err := NewProcessRule("nginx", "", "", "/run/nginx.pid")
+	require.NoError(t, err)
+	c, err := NewProcessCollector(0, []ProcessRule{rule})
+	require.NoError(t, err)
+	c.list = func(_ context.Context) ([]processInfo, error) {
+		t.Error("processes must not be listed for pidfile-only rules")
+		return nil, nil
+	}
+	c.readFile = func(_ string) ([]byte, error) {
+		return []byte("30\n"), nil
+	}
+	c.usage = func(_ context.Context, pid int32) (processUsage, error) {
+		if pid != 30 {
+			return processUsage{}, errors.New("no such process")
+		}
+		return processUsage{RSS: 1 << 20, CreateTime: time.Now()}, nil
+	}
+
+	collection, err := c.Collect(context.Background())
+	require.NoError(t, err)
+	require.Len(t, collection, 5)
+	assert.Equal(t, float64(1<<20), *collection[0].Value)
+	assert.Equal(t, float64(1), *collection[4].Value)
+}
@@ -0,0 +1,71 @@
+package collector
+
+import (
+	"context"
+	"math/rand"
+	"runtime"
+	"time"
+
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/metrics"
+)
+
+// RuntimeName имя источника метрик рантайма Go
+const RuntimeName = "runtime"
+
+// RuntimeCollector собирает статистику памяти рантайма Go, счётчик опросов и случайное значение
+type RuntimeCollector struct {
+	interval time.Duration
+}
+
+func NewRuntimeCollector(interval time.Duration) *RuntimeCollector {
+	return &RuntimeCollector{
+		interval: interval,
+	}
+}
+
+func (c *RuntimeCollector) Name() string {
+	return RuntimeName
+}
+
+func (c *RuntimeCollector) Interval() time.Duration {
+	return c.interval
+}
+
+func (c *RuntimeCollector) Collect(_ context.Context) ([]metrics.Metrics, error) {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+
+	return []metrics.Metrics{
+		*metrics.NewCounter("PollCount", 1),
+
+		*metrics.NewGauge("Alloc", float64(memStats.Alloc)),
+		*metrics.NewGauge("BuckHashSys", float64(memStats.BuckHashSys)),
+		*metrics.NewGauge("Frees", float64(memStats.Frees)),
+		*metrics.NewGauge("GCCPUFraction", memStats.GCCPUFraction),
+		*metrics.NewGauge("GCSys", float64(memStats.GCSys)),
+		*metrics.NewGauge("HeapAlloc", float64(memStats.HeapAlloc)),
+		*metrics.NewGauge("HeapIdle", float64(memStats.HeapIdle)),
+		*metrics.NewGauge("HeapInuse", float64(memStats.HeapInuse)),
+		*metrics.NewGauge("HeapObjects", float64(memStats.HeapObjects)),
+		*metrics.NewGauge("HeapReleased", float64(memStats.HeapReleased)),
+		*metrics.NewGauge("HeapSys", float64(memStats.HeapSys)),
+		*metrics.NewGauge("LastGC", float64(memStats.LastGC)),
+		*metrics.NewGauge("Lookups", float64(memStats.Lookups)),
+		*metrics.NewGauge("MCacheInuse", float64(memStats.MCacheInuse)),
+		*metrics.NewGauge("MCacheSys", float64(memStats.MCacheSys)),
+		*metrics.NewGauge("MSpanInuse", float64(memStats.MSpanInuse)),
+		*metrics.NewGauge("MSpanSys", float64(memStats.MSpanSys)),
+		*metrics.NewGauge("Mallocs", float64(memStats.Mallocs)),
+		*metrics.NewGauge("NextGC", float64(memStats.NextGC)),
+		*metrics.NewGauge("NumForcedGC", float64(memStats.NumForcedGC)),
+		*metrics.NewGauge("NumGC", float64(memStats.NumGC)),
+		*metrics.NewGauge("OtherSys", float64(memStats.OtherSys)),
+		*metrics.NewGauge("PauseTotalNs", float64(memStats.PauseTotalNs)),
+		*metrics.NewGauge("StackInuse", float64(memStats.StackInuse)),
+		*metrics.NewGauge("StackSys", float64(memStats.StackSys)),
+		*metrics.NewGauge("Sys", float64(memStats.Sys)),
+		*metrics.NewGauge("TotalAlloc", float64(memStats.TotalAlloc)),
+
+		*metrics.NewGauge("RandomValue", rand.Float64()*10000),
+	}, nil
+}
@@ -0,0 +1,279 @@
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/metrics"
+)
+
+// ScrapeName префикс имён источников, опрашивающих HTTP-эндпоинты в формате Prometheus
+const ScrapeName = "scrape"
+
+const defaultScrapeTimeout = 5 * time.Second
+
+// ScrapeTarget HTTP-эндпоинт, отдающий метрики в текстовом формате Prometheus
+type ScrapeTarget struct {
+	Name     string            // Имя цели, источник называется scrape:<Name>
+	URL      string            // Адрес эндпоинта
+	Timeout  time.Duration     // Время на запрос, по умолчанию 5s
+	Labels   map[string]string // Метки, добавляемые ко всем метрикам цели
+	Interval time.Duration     // Интервал опроса
+}
+
+// ScrapeCollector опрашивает эндпоинт и превращает счётчики и датчики Prometheus в метрики.
+// Счётчики отправляются приростом с предыдущего опроса, нетипизированные метрики считаются датчиками,
+// гистограммы и сводки пропускаются
+type ScrapeCollector struct {
+	target ScrapeTarget
+	client *resty.Client
+
+	mu       sync.Mutex
+	reported map[string]float64 // Значение счётчика, уже отправленное приростами
+}
+
+func NewScrapeCollector(target ScrapeTarget) (*ScrapeCollector, error) {
+	if target.Name == "" {
+		return nil, errors.New("scrape target has an empty name")
+	}
+	if target.URL == "" {
+		return nil, fmt.Errorf("scrape target %q has an empty url", target.Name)
+	}
+	if target.Timeout <= 0 {
+		target.Timeout = defaultScrapeTimeout
+	}
+
+	return &ScrapeCollector{
+		target: target,
+		client: resty.New().
+			SetTimeout(target.Timeout).
+			SetHeader("Accept", "text/plain;version=0.0.4"),
+	}, nil
+}
+
+func (c *ScrapeCollector) Name() string {
+	return ScrapeName + ":" + c.target.Name
+}
+
+func (c *ScrapeCollector) Interval() time.Duration {
+	return c.target.Interval
+}
+
+func (c *ScrapeCollector) Collect(ctx context.Context) ([]metrics.Metrics, error) {
+	res, err := c.client.R().
+		SetContext(ctx).
+		Get(c.target.URL)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", c.Name(), err)
+	}
+	if res.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("%s: unexpected status code %d", c.Name(), res.StatusCode())
+	}
+
+	samples, parseErr := parsePrometheusText(bytes.NewReader(res.Body()))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	prev := c.reported
+	c.reported = make(map[string]float64)
+
+	collection := make([]metrics.Metrics, 0, len(samples))
+	for _, sample := range samples {
+		labels := sample.Labels
+		if len(c.target.Labels) > 0 {
+			labels = make(map[string]string, len(sample.Labels)+len(c.target.Labels))
+			for name, value := range c.target.Labels {
+				labels[name] = value
+			}
+			for name, value := range sample.Labels {
+				labels[name] = value
+			}
+		}
+
+		switch sample.Type {
+		case prometheusCounter:
+			key := metrics.Key(sample.Name, labels)
+			old, ok := prev[key]
+			if !ok || sample.Value < old {
+				// первый опрос или сброс счётчика служат новой точкой отсчёта
+				c.reported[key] = sample.Value
+				continue
+			}
+			delta := int64(sample.Value - old)
+			c.reported[key] = old + float64(delta)
+			collection = append(collection, *metrics.NewCounter(sample.Name, delta).WithLabels(labels))
+		case prometheusGauge, prometheusUntyped:
+			if math.IsNaN(sample.Value) || math.IsInf(sample.Value, 0) {
+				continue
+			}
+			collection = append(collection, *metrics.NewGauge(sample.Name, sample.Value).WithLabels(labels))
+		}
+	}
+
+	if parseErr != nil {
+		return collection, fmt.Errorf("%s: %w", c.Name(), parseErr)
+	}
+	return collection, nil
+}
+
+const (
+	prometheusCounter   = "counter"
+	prometheusGauge     = "gauge"
+	prometheusHistogram = "histogram"
+	prometheusSummary   = "summary"
+	prometheusUntyped   = "untyped"
+)
+
+type prometheusSample struct {
+	Name   string
+	Type   string
+	Labels map[string]string
+	Value  float64
+}
+
+// parsePrometheusText разбирает текстовый формат Prometheus. Тип сэмпла определяется по строке # TYPE
+// его семейства, для гистограмм и сводок учитываются суффиксы _bucket, _sum и _count.
+// Строки, которые не удалось разобрать, пропускаются с ошибкой
+func parsePrometheusText(r io.Reader) ([]prometheusSample, error) {
+	types := make(map[string]string)
+	var samples []prometheusSample
+	var errs []error
+
+	scanner := bufio.NewScanner(r)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			fields := strings.Fields(line)
+			if len(fields) >= 4 && fields[1] == "TYPE" {
+				types[fields[2]] = fields[3]
+			}
+			continue
+		}
+
+		sample, err := parsePrometheusSample(line)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %w", lineNumber, err))
+			continue
+		}
+		sample.Type = sampleType(types, sample.Name)
+		samples = append(samples, sample)
+	}
+	if err := scanner.Err(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return samples, joinErrors(errs)
+}
+
+func sampleType(types map[string]string, name string) string {
+	if metricType, ok := types[name]; ok {
+		return metricType
+	}
+	for _, suffix := range []string{"_bucket", "_sum", "_count"} {
+		if !strings.HasSuffix(name, suffix) {
+			continue
+		}
+		switch types[strings.TrimSuffix(name, suffix)] {
+		case prometheusHistogram, prometheusSummary:
+			return types[strings.TrimSuffix(name, suffix)]
+		}
+	}
+	return prometheusUntyped
+}
+
+// parsePrometheusSample разбирает строку вида name{label="value",...} value [timestamp]
+func parsePrometheusSample(line string) (prometheusSample, error) {
+	var sample prometheusSample
+
+	nameEnd := strings.IndexAny(line, "{ \t")
+	if nameEnd <= 0 {
+		return sample, fmt.Errorf("invalid sample %q", line)
+	}
+	sample.Name = line[:nameEnd]
+	rest := line[nameEnd:]
+
+	if strings.HasPrefix(rest, "{") {
+		labels, tail, err := parsePrometheusLabels(rest[1:])
+		if err != nil {
+			return sample, err
+		}
+		sample.Labels = labels
+		rest = tail
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) == 0 || len(fields) > 2 {
+		return sample, fmt.Errorf("invalid value in %q", line)
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return sample, fmt.Errorf("invalid value %q", fields[0])
+	}
+	sample.Value = value
+
+	return sample, nil
+}
+
+// parsePrometheusLabels разбирает метки после открывающей скобки и возвращает остаток строки после закрывающей
+func parsePrometheusLabels(s string) (map[string]string, string, error) {
+	labels := make(map[string]string)
+	for {
+		s = strings.TrimLeft(s, " \t,")
+		if strings.HasPrefix(s, "}") {
+			return labels, s[1:], nil
+		}
+
+		eq := strings.IndexByte(s, '=')
+		if eq <= 0 || len(s) < eq+2 || s[eq+1] != '"' {
+			return nil, "", errors.New("invalid labels")
+		}
+		name := strings.TrimSpace(s[:eq])
+		s = s[eq+2:]
+
+		var value strings.Builder
+		closed := false
+		for i := 0; i < len(s); i++ {
+			switch s[i] {
+			case '\\':
+				if i+1 == len(s) {
+					return nil, "", errors.New("invalid escape in label value")
+				}
+				i++
+				switch s[i] {
+				case 'n':
+					value.WriteByte('\n')
+				default:
+					value.WriteByte(s[i])
+				}
+				continue
+			case '"':
+				s = s[i+1:]
+				closed = true
+			default:
+				value.WriteByte(s[i])
+				continue
+			}
+			break
+		}
+		if !closed {
+			return nil, "", errors.New("unterminated label value")
+		}
+		labels[name] = value.String()
+	}
+}
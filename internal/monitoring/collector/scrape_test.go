@@ -0,0 +1,102 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/metrics"
+)
+
+func TestParsePrometheusText(t *testing.T) {
+	samples, err := parsePrometheusText(strings.NewReader(`
+# HELP http_requests_total Total requests.
+# TYPE http_requests_total counter
+http_requests_total{method="post",code="200"} 1027 1395066363000
+http_requests_total{method="get",path="C:\\dir\\",msg="say \"hi\"\n"} 3
+# TYPE queue_depth gauge
+queue_depth 12.5
+up 1
+# TYPE rpc_duration_seconds summary
+rpc_duration_seconds{quantile="0.5"} 0.05
+rpc_duration_seconds_sum 17
+rpc_duration_seconds_count 2693
+# TYPE latency histogram
+latency_bucket{le="+Inf"} 10
+broken{method="get} 1
+no_value
+`))
+	assert.Error(t, err, "invalid lines must be reported")
+	assert.Equal(t, []prometheusSample{
+		{Name: "http_requests_total", Type: prometheusCounter, Labels: map[string]string{"method": "post", "code": "200"}, Value: 1027},
+		{Name: "http_requests_total", Type: prometheusCounter, Labels: map[string]string{"method": "get", "path": `C:\dir\`, "msg": "say \"hi\"\n"}, Value: 3},
+		{Name: "queue_depth", Type: prometheusGauge, Value: 12.5},
+		{Name: "up", Type: prometheusUntyped, Value: 1},
+		{Name: "rpc_duration_seconds", Type: prometheusSummary, Labels: map[string]string{"quantile": "0.5"}, Value: 0.05},
+		{Name: "rpc_duration_seconds_sum", Type: prometheusSummary, Value: 17},
+		{Name: "rpc_duration_seconds_count", Type: prometheusSummary, Value: 2693},
+		{Name: "latency_bucket", Type: prometheusHistogram, Labels: map[string]string{"le": "+Inf"}, Value: 10},
+	}, samples)
+}
+
+func TestScrapeCollector_Collect(t *testing.T) {
+	requests := 0
+	values := []float64{10.5, 15.25, 3}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		value := values[requests]
+		requests++
+		fmt.Fprintf(w, "# TYPE jobs_total counter\njobs_total{queue=\"emails\"} %g\n", value)
+		fmt.Fprint(w, "# TYPE temperature gauge\ntemperature 36.6\nnot_a_number NaN\n")
+		fmt.Fprint(w, "# TYPE latency histogram\nlatency_count 5\n")
+	}))
+	defer server.Close()
+
+	c, err := NewScrapeCollector(ScrapeTarget{
+		Name:   "app",
+		URL:    server.URL,
+		Labels: map[string]string{"job": "app", "queue": "overridden"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "scrape:app", c.Name())
+
+	labels := map[string]string{"job": "app", "queue": "emails"}
+	gauge := *metrics.NewGauge("temperature", 36.6).WithLabels(map[string]string{"job": "app", "queue": "overridden"})
+
+	collection, err := c.Collect(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []metrics.Metrics{gauge}, collection, "the first scrape only sets the counter baseline")
+
+	collection, err = c.Collect(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []metrics.Metrics{
+		*metrics.NewCounter("jobs_total", 4).WithLabels(labels),
+		gauge,
+	}, collection)
+
+	collection, err = c.Collect(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []metrics.Metrics{gauge}, collection, "a counter reset sets a new baseline")
+}
+
+func TestScrapeCollector_CollectErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	c, err := NewScrapeCollector(ScrapeTarget{Name: "down", URL: server.URL})
+	require.NoError(t, err)
+	_, err = c.Collect(context.Background())
+	assert.Error(t, err)
+
+	_, err = NewScrapeCollector(ScrapeTarget{Name: "no-url"})
+	assert.Error(t, err)
+	_, err = NewScrapeCollector(ScrapeTarget{URL: server.URL})
+	assert.Error(t, err)
+}
@@ -0,0 +1,60 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/shirou/gopsutil/cpu"
+	"github.com/shirou/gopsutil/mem"
+
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/metrics"
+)
+
+// SystemName имя источника системных метрик памяти и процессора
+const SystemName = "system"
+
+// SystemCollector собирает объём памяти и общую загрузку процессора CPUutilization1 через gopsutil.
+// Подробные метрики процессора собирает CPUCollector
+type SystemCollector struct {
+	interval time.Duration
+}
+
+func NewSystemCollector(interval time.Duration) *SystemCollector {
+	return &SystemCollector{
+		interval: interval,
+	}
+}
+
+func (c *SystemCollector) Name() string {
+	return SystemName
+}
+
+func (c *SystemCollector) Interval() time.Duration {
+	return c.interval
+}
+
+// Collect возвращает метрики памяти, даже если не удалось прочитать загрузку процессора, и наоборот
+func (c *SystemCollector) Collect(ctx context.Context) ([]metrics.Metrics, error) {
+	var collection []metrics.Metrics
+	var errs []error
+
+	v, err := mem.VirtualMemoryWithContext(ctx)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("reading virtual memory: %w", err))
+	} else {
+		collection = append(collection,
+			*metrics.NewGauge("TotalMemory", float64(v.Total)),
+			*metrics.NewGauge("FreeMemory", float64(v.Free)),
+		)
+	}
+
+	CPUUtilization, err := cpu.PercentWithContext(ctx, 0, false)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("reading CPU utilization: %w", err))
+	} else if len(CPUUtilization) > 0 {
+		collection = append(collection, *metrics.NewGauge("CPUutilization1", CPUUtilization[0]))
+	}
+
+	return collection, joinErrors(errs)
+}
@@ -2,109 +2,177 @@
 package monitoring
 
 import (
-	"math/rand"
-	"runtime"
+	"context"
+	"errors"
+	"sync"
 
 	"github.com/rs/zerolog/log"
-	"github.com/shirou/gopsutil/cpu"
-	"github.com/shirou/gopsutil/mem"
 
 	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/client"
 	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/factory/storage"
 	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/metrics"
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/monitoring/collector"
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/spool"
 )
 
 // IMonitor интерфейс для сбора метрик
 type IMonitor interface {
-	GatherMain()       // Собирает основные метрики
-	GatherAdditional() // Собирает дополнительные метрики
-	Send()             // Отправляет метрики
+	Collectors() []collector.Collector                 // Возвращает источники метрик
+	Gather(ctx context.Context, c collector.Collector) // Собирает метрики из источника
+	Send()                                             // Отправляет метрики
 }
 
 type Monitor struct {
 	storage  storage.Storager
 	client   *client.Client
-	memStats *runtime.MemStats
+	registry *collector.Registry
+	spool    *spool.Spool
+	sendMu   sync.Mutex
 }
 
-func NewMonitor(storage storage.Storager, client *client.Client) *Monitor {
+func NewMonitor(storage storage.Storager, client *client.Client, registry *collector.Registry) *Monitor {
 	return &Monitor{
 		storage:  storage,
 		client:   client,
-		memStats: &runtime.MemStats{},
+		registry: registry,
 	}
 }
 
-func (m *Monitor) GatherMain() {
-	log.Info().Msg("Gathering main...")
-	runtime.ReadMemStats(m.memStats)
-
-	_ = m.storage.Store(*metrics.NewCounter("PollCount", 1))
-
-	_ = m.storage.Store(*metrics.NewGauge("Alloc", float64(m.memStats.Alloc)))
-	_ = m.storage.Store(*metrics.NewGauge("BuckHashSys", float64(m.memStats.BuckHashSys)))
-	_ = m.storage.Store(*metrics.NewGauge("Frees", float64(m.memStats.Frees)))
-	_ = m.storage.Store(*metrics.NewGauge("GCCPUFraction", float64(m.memStats.GCCPUFraction)))
-	_ = m.storage.Store(*metrics.NewGauge("GCSys", float64(m.memStats.GCSys)))
-	_ = m.storage.Store(*metrics.NewGauge("HeapAlloc", float64(m.memStats.HeapAlloc)))
-	_ = m.storage.Store(*metrics.NewGauge("HeapIdle", float64(m.memStats.HeapIdle)))
-	_ = m.storage.Store(*metrics.NewGauge("HeapInuse", float64(m.memStats.HeapInuse)))
-	_ = m.storage.Store(*metrics.NewGauge("HeapObjects", float64(m.memStats.HeapObjects)))
-	_ = m.storage.Store(*metrics.NewGauge("HeapReleased", float64(m.memStats.HeapReleased)))
-	_ = m.storage.Store(*metrics.NewGauge("HeapSys", float64(m.memStats.HeapSys)))
-	_ = m.storage.Store(*metrics.NewGauge("LastGC", float64(m.memStats.LastGC)))
-	_ = m.storage.Store(*metrics.NewGauge("Lookups", float64(m.memStats.Lookups)))
-	_ = m.storage.Store(*metrics.NewGauge("MCacheInuse", float64(m.memStats.MCacheInuse)))
-	_ = m.storage.Store(*metrics.NewGauge("MCacheSys", float64(m.memStats.MCacheSys)))
-	_ = m.storage.Store(*metrics.NewGauge("MSpanInuse", float64(m.memStats.MSpanInuse)))
-	_ = m.storage.Store(*metrics.NewGauge("MSpanSys", float64(m.memStats.MSpanSys)))
-	_ = m.storage.Store(*metrics.NewGauge("Mallocs", float64(m.memStats.Mallocs)))
-	_ = m.storage.Store(*metrics.NewGauge("NextGC", float64(m.memStats.NextGC)))
-	_ = m.storage.Store(*metrics.NewGauge("NumForcedGC", float64(m.memStats.NumForcedGC)))
-	_ = m.storage.Store(*metrics.NewGauge("NumGC", float64(m.memStats.NumGC)))
-	_ = m.storage.Store(*metrics.NewGauge("OtherSys", float64(m.memStats.OtherSys)))
-	_ = m.storage.Store(*metrics.NewGauge("PauseTotalNs", float64(m.memStats.PauseTotalNs)))
-	_ = m.storage.Store(*metrics.NewGauge("StackInuse", float64(m.memStats.StackInuse)))
-	_ = m.storage.Store(*metrics.NewGauge("StackSys", float64(m.memStats.StackSys)))
-	_ = m.storage.Store(*metrics.NewGauge("Sys", float64(m.memStats.Sys)))
-	_ = m.storage.Store(*metrics.NewGauge("TotalAlloc", float64(m.memStats.TotalAlloc)))
-
-	random := rand.Float64() * (10000)
-	_ = m.storage.Store(*metrics.NewGauge("RandomValue", random))
+// SetSpool задаёт очередь на диске для пачек, которые не удалось отправить
+func (m *Monitor) SetSpool(spool *spool.Spool) {
+	m.spool = spool
 }
 
-func (m *Monitor) GatherAdditional() {
-	log.Info().Msg("Gathering additional...")
-	v, _ := mem.VirtualMemory()
-	CPUUtilization, _ := cpu.Percent(0, false)
+func (m *Monitor) Collectors() []collector.Collector {
+	return m.registry.Collectors()
+}
+
+func (m *Monitor) Gather(ctx context.Context, c collector.Collector) {
+	log.Info().Str("collector", c.Name()).Msg("Gathering...")
+	collection, err := c.Collect(ctx)
+	if err != nil {
+		log.Error().Err(err).Str("collector", c.Name()).Msg("Collecting metrics")
+	}
 
-	_ = m.storage.Store(*metrics.NewGauge("TotalMemory", float64(v.Total)))
-	_ = m.storage.Store(*metrics.NewGauge("FreeMemory", float64(v.Free)))
-	_ = m.storage.Store(*metrics.NewGauge("CPUutilization1", CPUUtilization[0]))
+	for _, metric := range collection {
+		if err = m.storage.Store(metric); err != nil {
+			log.Warn().Err(err).Str("collector", c.Name()).Str("metric", metric.ID).Msg("Storing a collected metric")
+		}
+	}
 }
 
 func (m *Monitor) Send() {
+	m.sendMu.Lock()
+	defer m.sendMu.Unlock()
+
 	log.Debug().Msg("Reporting...")
-	collection, err := m.storage.GetCollection()
+	collection, swapped, err := m.takePending()
 	if err != nil {
 		log.Error().Err(err).Send()
 		return
 	}
 
+	if err = m.replaySpool(); err != nil {
+		log.Error().Err(err).Msg("Replaying spooled batches")
+		m.keepUnsent(collection, swapped)
+		return
+	}
+
 	if len(collection) == 0 {
 		log.Error().Msg("Empty collection, nothing to send to the server")
 		return
 	}
 
 	if err = m.client.UpdateMetricsBatchJSON(collection); err != nil {
+		if !errors.Is(err, client.ErrRejected) {
+			log.Error().Err(err).Send()
+			m.keepUnsent(collection, swapped)
+			return
+		}
+		// повторная отправка получит тот же отказ и задержит следующие пачки
+		log.Error().Err(err).Int("metrics", len(collection)).Msg("A collection of metrics was rejected and dropped")
+	} else {
+		log.Info().Msg("A collection of metrics was sent for update")
+	}
+
+	if swapped {
+		return
+	}
+	if err = m.storage.CleanUp(); err != nil {
 		log.Error().Err(err).Send()
 		return
 	}
+}
 
-	log.Info().Msg("A collection of metrics was sent for update")
+// takePending забирает метрики для отправки. Если хранилище поддерживает snapshot-and-swap,
+// накопленные значения забираются атомарно, и сбор продолжается в пустое хранилище,
+// поэтому счётчики, добавленные во время отправки, не теряются при очистке
+func (m *Monitor) takePending() (map[string]metrics.Metrics, bool, error) {
+	if swapper, ok := m.storage.(storage.Swapper); ok {
+		if _, ok = m.storage.(storage.Merger); ok {
+			collection, err := swapper.Swap()
+			return collection, true, err
+		}
+	}
 
-	if err = m.storage.CleanUp(); err != nil {
-		log.Error().Err(err).Send()
+	collection, err := m.storage.GetCollection()
+	return collection, false, err
+}
+
+// keepUnsent сохраняет неотправленную пачку: в очередь на диске, а если её нет или запись не удалась —
+// возвращает забранные значения обратно в хранилище
+func (m *Monitor) keepUnsent(collection map[string]metrics.Metrics, swapped bool) {
+	if len(collection) == 0 {
+		return
+	}
+
+	if m.spoolCollection(collection) {
+		if !swapped {
+			// очищаем, чтобы следующий сбор не перезаписал значения, уже лежащие в очереди
+			if err := m.storage.CleanUp(); err != nil {
+				log.Error().Err(err).Send()
+			}
+		}
 		return
 	}
+
+	if !swapped {
+		// значения остались в хранилище и уйдут со следующей отправкой
+		return
+	}
+	if err := m.storage.(storage.Merger).Merge(collection); err != nil {
+		log.Error().Err(err).Msg("Merging unsent metrics back into the storage")
+		return
+	}
+	log.Warn().Int("metrics", len(collection)).Msg("Unsent metrics were merged back into the storage")
+}
+
+// replaySpool отправляет накопленные в очереди пачки, чтобы они дошли до сервера раньше новых
+func (m *Monitor) replaySpool() error {
+	if m.spool == nil || m.spool.Len() == 0 {
+		return nil
+	}
+
+	sent, err := m.spool.Replay(m.client.UpdateMetricsBatchJSON, func(err error) bool {
+		return errors.Is(err, client.ErrRejected)
+	})
+	if sent > 0 {
+		log.Info().Int("batches", sent).Msg("Spooled batches were sent for update")
+	}
+	return err
+}
+
+// spoolCollection откладывает неотправленную пачку в очередь на диске
+func (m *Monitor) spoolCollection(collection map[string]metrics.Metrics) bool {
+	if m.spool == nil {
+		return false
+	}
+
+	if err := m.spool.Push(collection); err != nil {
+		log.Error().Err(err).Msg("Spooling a batch of metrics")
+		return false
+	}
+	log.Warn().Int("spooled", m.spool.Len()).Msg("A collection of metrics was spooled")
+
+	return true
 }
@@ -1,20 +1,219 @@
 package monitoring
 
 import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/client"
 	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/factory"
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/factory/storage/memory"
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/metrics"
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/monitoring/collector"
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/spool"
 )
 
 func TestNewMonitor(t *testing.T) {
 	memoryStorage := (&factory.StorageFactory{}).CreateStorage()
 	clientToServer := client.NewClient("https://test.com", 60*time.Second, "secret", "")
 
-	monitor := NewMonitor(memoryStorage, clientToServer)
+	monitor := NewMonitor(memoryStorage, clientToServer, collector.NewRegistry())
 
 	assert.Implements(t, (*IMonitor)(nil), monitor)
 }
+
+func TestMonitor_SendSpoolsWhenServerIsDown(t *testing.T) {
+	var down atomic.Value
+	down.Store(true)
+	var received int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load().(bool) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		atomic.AddInt32(&received, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	memoryStorage := memory.NewMemoryStorage()
+	sendSpool, err := spool.New(t.TempDir(), 0)
+	require.NoError(t, err)
+
+	sender := client.NewClient(server.URL, time.Second, "", "").SetRetryPolicy(client.RetryPolicy{MaxAttempts: 1})
+	monitor := NewMonitor(memoryStorage, sender, collector.NewRegistry())
+	monitor.SetSpool(sendSpool)
+
+	require.NoError(t, memoryStorage.Store(*metrics.NewGauge("Alloc", 1)))
+	monitor.Send()
+	require.NoError(t, memoryStorage.Store(*metrics.NewGauge("Alloc", 2)))
+	monitor.Send()
+
+	assert.Equal(t, 2, sendSpool.Len(), "both batches must survive while the server is down")
+	collection, err := memoryStorage.GetCollection()
+	require.NoError(t, err)
+	assert.Empty(t, collection, "spooled metrics must not stay in the storage")
+
+	down.Store(false)
+	require.NoError(t, memoryStorage.Store(*metrics.NewGauge("Alloc", 3)))
+	monitor.Send()
+
+	assert.Equal(t, 0, sendSpool.Len())
+	assert.Equal(t, int32(3), atomic.LoadInt32(&received))
+}
+
+func TestMonitor_SendDropsRejectedBatches(t *testing.T) {
+	var status atomic.Value
+	status.Store(http.StatusServiceUnavailable)
+	var received int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		code := status.Load().(int)
+		if code == http.StatusOK {
+			atomic.AddInt32(&received, 1)
+		} else if code == http.StatusBadRequest {
+			// сервер отклоняет только первую пачку
+			status.Store(http.StatusOK)
+		}
+		w.WriteHeader(code)
+	}))
+	defer server.Close()
+
+	memoryStorage := memory.NewMemoryStorage()
+	sendSpool, err := spool.New(t.TempDir(), 0)
+	require.NoError(t, err)
+
+	sender := client.NewClient(server.URL, time.Second, "", "").SetRetryPolicy(client.RetryPolicy{MaxAttempts: 1})
+	monitor := NewMonitor(memoryStorage, sender, collector.NewRegistry())
+	monitor.SetSpool(sendSpool)
+
+	require.NoError(t, memoryStorage.Store(*metrics.NewGauge("Alloc", 1)))
+	monitor.Send()
+	assert.Equal(t, 1, sendSpool.Len())
+
+	status.Store(http.StatusBadRequest)
+	require.NoError(t, memoryStorage.Store(*metrics.NewGauge("Alloc", 2)))
+	monitor.Send()
+	assert.Equal(t, 0, sendSpool.Len(), "a rejected spooled batch is dropped")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&received), "batches after the rejected one are sent")
+
+	status.Store(http.StatusBadRequest)
+	require.NoError(t, memoryStorage.Store(*metrics.NewGauge("Alloc", 3)))
+	monitor.Send()
+	assert.Equal(t, 0, sendSpool.Len(), "a rejected collection is not spooled")
+	collection, err := memoryStorage.GetCollection()
+	require.NoError(t, err)
+	assert.Empty(t, collection)
+}
+
+func TestMonitor_SendDoesNotLoseCounters(t *testing.T) {
+	var down atomic.Value
+	down.Store(true)
+	var received int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load().(bool) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		// require вызывает t.FailNow, который нельзя вызывать вне горутины теста
+		gz, err := gzip.NewReader(r.Body)
+		if !assert.NoError(t, err) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var batch []metrics.Metrics
+		if !assert.NoError(t, json.NewDecoder(gz).Decode(&batch)) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		for _, m := range batch {
+			if m.ID == "PollCount" {
+				atomic.AddInt64(&received, *m.Delta)
+			}
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	memoryStorage := memory.NewMemoryStorage()
+	sender := client.NewClient(server.URL, time.Second, "", "").SetRetryPolicy(client.RetryPolicy{MaxAttempts: 1})
+	monitor := NewMonitor(memoryStorage, sender, collector.NewRegistry())
+
+	const polls = 500
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < polls; i++ {
+			_ = memoryStorage.Store(*metrics.NewCounter("PollCount", 1))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			if i == 10 {
+				down.Store(false)
+			}
+			monitor.Send()
+		}
+	}()
+	wg.Wait()
+
+	down.Store(false)
+	monitor.Send()
+
+	assert.Equal(t, int64(polls), atomic.LoadInt64(&received))
+}
+
+type stubCollector struct {
+	collection []metrics.Metrics
+	err        error
+}
+
+func (c *stubCollector) Name() string {
+	return "stub"
+}
+
+func (c *stubCollector) Interval() time.Duration {
+	return 0
+}
+
+func (c *stubCollector) Collect(_ context.Context) ([]metrics.Metrics, error) {
+	return c.collection, c.err
+}
+
+func TestMonitor_Gather(t *testing.T) {
+	memoryStorage := memory.NewMemoryStorage()
+	registry := collector.NewRegistry()
+	stub := &stubCollector{
+		collection: []metrics.Metrics{
+			*metrics.NewCounter("Requests", 2),
+			*metrics.NewGauge("Temperature", 36.6),
+			*metrics.NewGauge("", 1),
+		},
+		err: errors.New("partially failed"),
+	}
+	require.NoError(t, registry.Register(stub))
+
+	monitor := NewMonitor(memoryStorage, client.NewClient("https://test.com", time.Second, "", ""), registry)
+	for _, c := range monitor.Collectors() {
+		monitor.Gather(context.Background(), c)
+		monitor.Gather(context.Background(), c)
+	}
+
+	collection, err := memoryStorage.GetCollection()
+	require.NoError(t, err)
+	assert.Len(t, collection, 2, "invalid metrics must be skipped, partial results kept")
+	assert.Equal(t, int64(4), *collection["Requests"].Delta)
+	assert.Equal(t, 36.6, *collection["Temperature"].Value)
+}
@@ -30,6 +30,9 @@ func (s *Server) PingDBHandler(rw http.ResponseWriter, _ *http.Request) {
 	rw.WriteHeader(http.StatusOK)
 }
 
+// quantileParam query-параметр для запроса квантиля сводки, например, /value/summary/Latency?quantile=0.99
+const quantileParam = "quantile"
+
 func (s *Server) UpdateMetricHandler(rw http.ResponseWriter, r *http.Request) {
 	//if r.Header.Get("Content-Type") != "text/plain" {
 	//	String(rw, http.StatusBadRequest, "Invalid Content-Type")
@@ -51,6 +54,7 @@ func (s *Server) UpdateMetricHandler(rw http.ResponseWriter, r *http.Request) {
 		String(rw, http.StatusBadRequest, "Invalid metric value")
 		return
 	}
+	labels := LabelsFromQuery(r)
 
 	switch metricType {
 	case metrics.StringCounterType:
@@ -58,7 +62,7 @@ func (s *Server) UpdateMetricHandler(rw http.ResponseWriter, r *http.Request) {
 		if err != nil {
 			panic(err)
 		}
-		if err := s.storage.Store(*metrics.NewCounter(metricName, v)); err != nil {
+		if err := s.storage.Store(*metrics.NewCounter(metricName, v).WithLabels(labels)); err != nil {
 			String(rw, http.StatusInternalServerError, err.Error())
 			return
 		}
@@ -67,16 +71,17 @@ func (s *Server) UpdateMetricHandler(rw http.ResponseWriter, r *http.Request) {
 		if err != nil {
 			panic(err)
 		}
-		if err := s.storage.Store(*metrics.NewGauge(metricName, v)); err != nil {
+		if err := s.storage.Store(*metrics.NewGauge(metricName, v).WithLabels(labels)); err != nil {
 			String(rw, http.StatusInternalServerError, err.Error())
 			return
 		}
 	}
+	s.touch(metrics.Key(metricName, labels))
 }
 
 func (s *Server) GetMetricHandler(rw http.ResponseWriter, r *http.Request) {
 	metricType := chi.URLParam(r, "type")
-	if !(metricType == metrics.StringCounterType || metricType == metrics.StringGaugeType) {
+	if !(metricType == metrics.StringCounterType || metricType == metrics.StringGaugeType || metricType == metrics.StringSummaryType) {
 		String(rw, http.StatusNotImplemented, "Wrong metric type")
 		return
 	}
@@ -86,7 +91,17 @@ func (s *Server) GetMetricHandler(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	value, err := s.storage.Get(*metrics.New(metricType, metricName))
+	quantile := 0.5
+	if metricType == metrics.StringSummaryType && r.URL.Query().Has(quantileParam) {
+		q, err := strconv.ParseFloat(r.URL.Query().Get(quantileParam), 64)
+		if err != nil || q < 0 || q > 1 {
+			String(rw, http.StatusBadRequest, "Invalid quantile")
+			return
+		}
+		quantile = q
+	}
+
+	value, err := s.storage.Get(*metrics.New(metricType, metricName).WithLabels(LabelsFromQuery(r, quantileParam)))
 	if err != nil {
 		if errors.Is(err, metrics.ErrNoValue) {
 			String(rw, http.StatusNotFound, "")
@@ -99,5 +114,11 @@ func (s *Server) GetMetricHandler(rw http.ResponseWriter, r *http.Request) {
 		String(rw, http.StatusOK, fmt.Sprintf("%v", *value.Delta))
 	case metrics.StringGaugeType:
 		String(rw, http.StatusOK, fmt.Sprintf("%v", *value.Value))
+	case metrics.StringSummaryType:
+		if value.Summary == nil || value.Summary.Count == 0 {
+			String(rw, http.StatusNotFound, "")
+			return
+		}
+		String(rw, http.StatusOK, fmt.Sprintf("%v", value.Summary.Quantile(quantile)))
 	}
 }
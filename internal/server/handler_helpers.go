@@ -1,10 +1,11 @@
 package server
 
 import (
-	"encoding/json"
 	"net/http"
 
 	"github.com/rs/zerolog/log"
+
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/httpjson"
 )
 
 func NotFound(rw http.ResponseWriter, r *http.Request) {
@@ -32,21 +33,34 @@ func String(rw http.ResponseWriter, code int, text string) {
 }
 
 func JSON(rw http.ResponseWriter, code int, obj any) {
-	rw.Header().Set("Content-Type", "application/json")
-	rw.WriteHeader(code)
-	jsonBytes, errMarshal := json.Marshal(obj)
-	if errMarshal != nil {
-		log.Error().Err(errMarshal).Msg("JSON marshling")
-	}
-	if _, err := rw.Write(jsonBytes); err != nil {
-		log.Error().Err(err).Msg("Writing response")
-	}
+	httpjson.Write(rw, code, obj)
 }
 
 func ParseJSON(r *http.Request, obj any) error {
-	decoder := json.NewDecoder(r.Body)
-	if err := decoder.Decode(obj); err != nil {
-		return err
+	return httpjson.Parse(r, obj)
+}
+
+// LabelsFromQuery возвращает метки метрики из query-параметров запроса, кроме зарезервированных
+func LabelsFromQuery(r *http.Request, reserved ...string) map[string]string {
+	var labels map[string]string
+	for name, values := range r.URL.Query() {
+		if name == "" || len(values) == 0 || contains(reserved, name) {
+			continue
+		}
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+		labels[name] = values[0]
+	}
+
+	return labels
+}
+
+func contains(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
 	}
-	return nil
+	return false
 }
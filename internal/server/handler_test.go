@@ -173,6 +173,14 @@ func TestUpdateMetricHandler(t *testing.T) {
 	}
 }
 
+func newLatencySummary() *metrics.Metrics {
+	summary, _ := metrics.NewSummary("Latency", 0.01)
+	for i := 1; i <= 100; i++ {
+		summary.Summary.Add(float64(i))
+	}
+	return summary
+}
+
 func TestGetMetricHandler(t *testing.T) {
 	type send struct {
 		uri         string
@@ -206,6 +214,65 @@ func TestGetMetricHandler(t *testing.T) {
 				response:     "5",
 			},
 		},
+		{
+			name: "OK with labels",
+			send: send{
+				uri:         "/value/gauge/Alloc?host=web-1",
+				contentType: "text/plain",
+				method:      http.MethodGet,
+			},
+			want: want{
+				metricGet:    metrics.New(metrics.StringGaugeType, "Alloc").WithLabels(map[string]string{"host": "web-1"}),
+				metricReturn: metrics.NewGauge("Alloc", 2.5).WithLabels(map[string]string{"host": "web-1"}),
+				err:          nil,
+				code:         200,
+				response:     "2.5",
+			},
+		},
+		{
+			name: "OK summary quantile",
+			send: send{
+				uri:         "/value/summary/Latency?quantile=0.99",
+				contentType: "text/plain",
+				method:      http.MethodGet,
+			},
+			want: want{
+				metricGet:    metrics.New(metrics.StringSummaryType, "Latency"),
+				metricReturn: newLatencySummary(),
+				err:          nil,
+				code:         200,
+				response:     "98.50457626879007",
+			},
+		},
+		{
+			name: "OK summary median by default",
+			send: send{
+				uri:         "/value/summary/Latency",
+				contentType: "text/plain",
+				method:      http.MethodGet,
+			},
+			want: want{
+				metricGet:    metrics.New(metrics.StringSummaryType, "Latency"),
+				metricReturn: newLatencySummary(),
+				err:          nil,
+				code:         200,
+				response:     "49.90296094906597",
+			},
+		},
+		{
+			name: "Invalid quantile",
+			send: send{
+				uri:         "/value/summary/Latency?quantile=2",
+				contentType: "text/plain",
+				method:      http.MethodGet,
+			},
+			want: want{
+				metricGet: metrics.New(metrics.StringSummaryType, "Latency"),
+				err:       nil,
+				code:      400,
+				response:  "Invalid quantile",
+			},
+		},
 		{
 			name: "Wrong metric type",
 			send: send{
@@ -268,7 +335,7 @@ func TestGetMetricHandler(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			ms := new(mockStorage)
-			ms.On("Get", *metrics.New(tt.want.metricGet.Type, tt.want.metricGet.ID)).Return(tt.want.metricReturn, tt.want.err)
+			ms.On("Get", *metrics.New(tt.want.metricGet.Type, tt.want.metricGet.ID).WithLabels(tt.want.metricGet.Labels)).Return(tt.want.metricReturn, tt.want.err)
 
 			ser := NewServer("some_address", ms, "", "")
 
@@ -7,11 +7,18 @@ import (
 
 	"github.com/rs/zerolog/log"
 
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/httpjson"
 	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/metrics"
 	"github.com/PostScripton/go-metrics-and-alerting-collection/pkg/hashing/hmac"
 )
 
-type JSONObj map[string]any
+type JSONObj = httpjson.Obj
+
+// summaryResponse сводка вместе с оценками квантилей по умолчанию
+type summaryResponse struct {
+	metrics.Metrics
+	Quantiles map[string]float64 `json:"quantiles,omitempty"`
+}
 
 var notFoundResponse = JSONObj{"message": "404 page not found"}
 var methodNotAllowed = JSONObj{"message": "405 method not allowed"}
@@ -28,24 +35,8 @@ func (s *Server) UpdateMetricJSONHandler(rw http.ResponseWriter, r *http.Request
 		return
 	}
 
-	if metricsRequest.ID == "" {
-		JSON(rw, http.StatusNotFound, JSONObj{"message": "No metric ID specified"})
-		return
-	}
-
-	switch metricsRequest.Type {
-	case metrics.StringCounterType:
-		if metricsRequest.Delta == nil {
-			JSON(rw, http.StatusNotFound, notFoundResponse)
-			return
-		}
-	case metrics.StringGaugeType:
-		if metricsRequest.Value == nil {
-			JSON(rw, http.StatusNotFound, notFoundResponse)
-			return
-		}
-	default:
-		JSON(rw, http.StatusNotImplemented, JSONObj{"message": "Invalid metric type"})
+	if err := metricsRequest.ValidateWithValue(); err != nil {
+		validationError(rw, &metricsRequest, err)
 		return
 	}
 
@@ -57,9 +48,10 @@ func (s *Server) UpdateMetricJSONHandler(rw http.ResponseWriter, r *http.Request
 	}
 
 	if err := s.storage.Store(metricsRequest); err != nil {
-		JSON(rw, http.StatusInternalServerError, JSONObj{"message": fmt.Sprintf("Error on storing data: %s", err)})
+		storeError(rw, err)
 		return
 	}
+	s.touch(metricsRequest.Key())
 
 	JSON(rw, http.StatusOK, JSONObj{})
 
@@ -81,10 +73,7 @@ func (s *Server) GetMetricJSONHandler(rw http.ResponseWriter, r *http.Request) {
 		JSON(rw, http.StatusNotFound, JSONObj{"message": "No metric ID specified"})
 		return
 	}
-	switch metricsReq.Type {
-	case metrics.StringCounterType:
-	case metrics.StringGaugeType:
-	default:
+	if !metrics.IsSupportedType(metricsReq.Type) {
 		JSON(rw, http.StatusNotImplemented, JSONObj{"message": "Invalid metric type"})
 		return
 	}
@@ -103,6 +92,14 @@ func (s *Server) GetMetricJSONHandler(rw http.ResponseWriter, r *http.Request) {
 		value.Hash = value.ToHexHash(hmac.NewHmacSigner(), s.key)
 	}
 
+	if value.Type == metrics.StringSummaryType {
+		JSON(rw, http.StatusOK, summaryResponse{
+			Metrics:   *value,
+			Quantiles: value.Quantiles(metrics.DefaultQuantiles...),
+		})
+		return
+	}
+
 	JSON(rw, http.StatusOK, value)
 }
 
@@ -117,6 +114,13 @@ func (s *Server) UpdateMetricsBatchJSONHandler(rw http.ResponseWriter, r *http.R
 		return
 	}
 
+	for _, m := range metricsCollection {
+		if err := m.ValidateWithValue(); err != nil {
+			JSON(rw, http.StatusBadRequest, JSONObj{"message": fmt.Sprintf("Invalid metric [%s]: %s", m.ID, err)})
+			return
+		}
+	}
+
 	if s.key != "" {
 		for _, m := range metricsCollection {
 			if !m.ValidHash(hmac.NewHmacSigner(), m.Hash, s.key) {
@@ -127,22 +131,53 @@ func (s *Server) UpdateMetricsBatchJSONHandler(rw http.ResponseWriter, r *http.R
 	}
 
 	var metricsMap = make(map[string]metrics.Metrics)
+	var ids = make([]string, 0, len(metricsCollection))
 	for _, m := range metricsCollection {
-		if old, ok := metricsMap[m.ID]; ok {
-			metrics.Update(&old, &m)
-			metricsMap[m.ID] = old
+		key := m.Key()
+		if old, ok := metricsMap[key]; ok {
+			if err := metrics.Update(&old, &m); err != nil {
+				storeError(rw, fmt.Errorf("metric [%s]: %w", key, err))
+				return
+			}
+			metricsMap[key] = old
 		} else {
-			metricsMap[m.ID] = m
+			metricsMap[key] = m
+			ids = append(ids, key)
 		}
 		log.Debug().Interface("metric", m).Msg("Metric of collection updated!")
 	}
 
 	if err := s.storage.StoreCollection(metricsMap); err != nil {
-		JSON(rw, http.StatusInternalServerError, JSONObj{"message": fmt.Sprintf("Error on storing data: %s", err)})
+		storeError(rw, err)
 		return
 	}
+	s.touch(ids...)
 
 	log.Info().Msg("Metrics collection updated")
 
 	JSON(rw, http.StatusOK, JSONObj{})
 }
+
+// validationError отвечает на запрос с метрикой, которая не прошла проверку
+func validationError(rw http.ResponseWriter, m *metrics.Metrics, err error) {
+	switch {
+	case errors.Is(err, metrics.ErrEmptyID):
+		JSON(rw, http.StatusNotFound, JSONObj{"message": "No metric ID specified"})
+	case errors.Is(err, metrics.ErrUnsupportedType):
+		JSON(rw, http.StatusNotImplemented, JSONObj{"message": "Invalid metric type"})
+	case errors.Is(err, metrics.ErrNoValue):
+		JSON(rw, http.StatusNotFound, notFoundResponse)
+	default:
+		JSON(rw, http.StatusBadRequest, JSONObj{"message": fmt.Sprintf("Invalid %s: %s", m.Type, err)})
+	}
+}
+
+// storeError отвечает на запрос, метрики которого не удалось сохранить. Значение, которое нельзя
+// объединить с сохранённым, это ошибка запроса
+func storeError(rw http.ResponseWriter, err error) {
+	if metrics.IsIncompatible(err) {
+		JSON(rw, http.StatusBadRequest, JSONObj{"message": fmt.Sprintf("Incompatible metric value: %s", err)})
+		return
+	}
+	JSON(rw, http.StatusInternalServerError, JSONObj{"message": fmt.Sprintf("Error on storing data: %s", err)})
+}
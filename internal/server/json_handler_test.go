@@ -7,10 +7,13 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/alerting"
 	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/metrics"
 )
 
@@ -43,6 +46,34 @@ func TestUpdateMetricJSONHandler(t *testing.T) {
 				response: JSONObj{},
 			},
 		},
+		{
+			name: "OK histogram",
+			send: send{
+				metrics:     metrics.NewHistogram("Latency", metrics.DefaultBuckets),
+				contentType: "application/json",
+				method:      http.MethodPost,
+			},
+			want: want{
+				code:     200,
+				response: JSONObj{},
+			},
+		},
+		{
+			name: "Invalid histogram",
+			send: send{
+				metrics: &metrics.Metrics{
+					ID:        "Latency",
+					Type:      metrics.StringHistogramType,
+					Histogram: &metrics.Histogram{Bounds: []float64{1}, Counts: []uint64{1}, Count: 1},
+				},
+				contentType: "application/json",
+				method:      http.MethodPost,
+			},
+			want: want{
+				code:     400,
+				response: JSONObj{"message": "Invalid histogram: histogram must have 2 counts for 1 bounds"},
+			},
+		},
 		{
 			name: "Invalid metric type",
 			send: send{
@@ -267,3 +298,120 @@ func TestGetMetricJSONHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdateMetricsBatchJSONHandler_TracksUpdates(t *testing.T) {
+	ms := new(mockStorage)
+	ms.On("StoreCollection", mock.Anything).Return(nil)
+
+	tracker := alerting.NewTracker()
+	ser := NewServer("some_address", ms, "", "")
+	ser.SetUpdateTracker(tracker)
+
+	jsonBytes, errJSON := json.Marshal([]metrics.Metrics{
+		*metrics.NewCounter("PollCount", 1),
+		*metrics.NewGauge("Alloc", 5),
+		*metrics.NewCounter("PollCount", 2),
+	})
+	require.NoError(t, errJSON)
+
+	req, errReq := http.NewRequest(http.MethodPost, "/updates", bytes.NewBuffer(jsonBytes))
+	req.Header.Set("Content-Type", "application/json")
+	require.NoError(t, errReq)
+
+	before := time.Now()
+	w := httptest.NewRecorder()
+	ser.router.ServeHTTP(w, req)
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	for _, id := range []string{"PollCount", "Alloc"} {
+		updated, ok := tracker.LastUpdated(id)
+		assert.True(t, ok, id)
+		assert.False(t, updated.Before(before), id)
+	}
+	_, ok := tracker.LastUpdated("HeapAlloc")
+	assert.False(t, ok)
+}
+
+func TestUpdateMetricsBatchJSONHandler_ValidatesMetrics(t *testing.T) {
+	broken := metrics.NewHistogram("Latency", []float64{1})
+	broken.Histogram.Counts = []uint64{1}
+
+	tests := []struct {
+		name    string
+		metrics []metrics.Metrics
+		message string
+	}{
+		{
+			name:    "Invalid histogram",
+			metrics: []metrics.Metrics{*metrics.NewCounter("PollCount", 1), *broken},
+			message: "Invalid metric [Latency]: histogram must have 2 counts for 1 bounds",
+		},
+		{
+			name:    "No value",
+			metrics: []metrics.Metrics{*metrics.New(metrics.StringGaugeType, "Alloc")},
+			message: "Invalid metric [Alloc]: no value",
+		},
+		{
+			name:    "Incompatible buckets",
+			metrics: []metrics.Metrics{*metrics.NewHistogram("Latency", []float64{1}), *metrics.NewHistogram("Latency", []float64{5})},
+			message: "Incompatible metric value: metric [Latency]: histogram buckets do not match",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms := new(mockStorage)
+			ser := NewServer("some_address", ms, "", "")
+
+			jsonBytes, errJSON := json.Marshal(tt.metrics)
+			require.NoError(t, errJSON)
+			req, errReq := http.NewRequest(http.MethodPost, "/updates", bytes.NewBuffer(jsonBytes))
+			require.NoError(t, errReq)
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			ser.router.ServeHTTP(w, req)
+			res := w.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+			var jsonRes JSONObj
+			require.NoError(t, json.NewDecoder(res.Body).Decode(&jsonRes))
+			assert.Equal(t, JSONObj{"message": tt.message}, jsonRes)
+			ms.AssertNotCalled(t, "StoreCollection", mock.Anything)
+		})
+	}
+}
+
+func TestGetMetricJSONHandler_SummaryQuantiles(t *testing.T) {
+	ms := new(mockStorage)
+	ms.On("Get", *metrics.New(metrics.StringSummaryType, "Latency")).Return(newLatencySummary(), nil)
+
+	ser := NewServer("some_address", ms, "", "")
+
+	jsonBytes, errJSON := json.Marshal(metrics.New(metrics.StringSummaryType, "Latency"))
+	require.NoError(t, errJSON)
+
+	req, errReq := http.NewRequest(http.MethodPost, "/value", bytes.NewBuffer(jsonBytes))
+	req.Header.Set("Content-Type", "application/json")
+	require.NoError(t, errReq)
+
+	w := httptest.NewRecorder()
+	ser.router.ServeHTTP(w, req)
+	res := w.Result()
+
+	defer res.Body.Close()
+	resBody, errReadBody := io.ReadAll(res.Body)
+	require.NoError(t, errReadBody)
+
+	var jsonRes summaryResponse
+	require.NoError(t, json.Unmarshal(resBody, &jsonRes))
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "Latency", jsonRes.ID)
+	assert.Equal(t, uint64(100), jsonRes.Summary.Count)
+	assert.InDelta(t, 50, jsonRes.Quantiles["0.5"], 1)
+	assert.InDelta(t, 90, jsonRes.Quantiles["0.9"], 1)
+	assert.InDelta(t, 99, jsonRes.Quantiles["0.99"], 1)
+}
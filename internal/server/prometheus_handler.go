@@ -0,0 +1,194 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/metrics"
+)
+
+const prometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// PrometheusMetricsHandler godoc
+// @Tags Info
+// @Summary Метрики в формате Prometheus
+// @Description Возвращает все метрики в текстовом формате экспозиции Prometheus
+// @Produce text/plain
+// @Success 200 {string} string ""
+// @Failure 500 {string} string ""
+// @Router /metrics [get]
+func (s *Server) PrometheusMetricsHandler(rw http.ResponseWriter, _ *http.Request) {
+	collection, err := s.storage.GetCollection()
+	if err != nil {
+		String(rw, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	rw.Header().Set("Content-Type", prometheusContentType)
+	rw.WriteHeader(http.StatusOK)
+	if _, err = rw.Write(RenderPrometheus(collection)); err != nil {
+		log.Error().Err(err).Msg("Writing response")
+	}
+}
+
+// RenderPrometheus переводит коллекцию метрик в текстовый формат экспозиции Prometheus
+func RenderPrometheus(collection map[string]metrics.Metrics) []byte {
+	families := make(map[string][]metrics.Metrics)
+	for _, metric := range collection {
+		name := SanitizePrometheusName(metric.ID)
+		families[name] = append(families[name], metric)
+	}
+
+	names := make([]string, 0, len(families))
+	for name := range families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	for _, name := range names {
+		family := families[name]
+		sort.Slice(family, func(i, j int) bool {
+			return family[i].Key() < family[j].Key()
+		})
+
+		familyType := family[0].Type
+		fmt.Fprintf(&buf, "# HELP %s Metric %s of type %s\n", name, escapePrometheusHelp(family[0].ID), familyType)
+		fmt.Fprintf(&buf, "# TYPE %s %s\n", name, familyType)
+
+		for _, metric := range family {
+			if metric.Type != familyType {
+				log.Warn().Str("id", metric.ID).Str("name", name).Msg("Metric name collides with a metric of another type")
+				continue
+			}
+
+			switch metric.Type {
+			case metrics.StringHistogramType:
+				writePrometheusHistogram(&buf, name, metric)
+				continue
+			case metrics.StringSummaryType:
+				writePrometheusSummary(&buf, name, metric)
+				continue
+			}
+
+			value, err := metric.Float()
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(&buf, "%s%s %s\n", name, formatPrometheusLabels(metric.Labels), formatPrometheusValue(value))
+		}
+	}
+
+	return buf.Bytes()
+}
+
+// writePrometheusHistogram выводит накопленные корзины гистограммы, её сумму и количество значений
+func writePrometheusHistogram(buf *bytes.Buffer, name string, metric metrics.Metrics) {
+	if metric.Histogram == nil {
+		return
+	}
+	h := metric.Histogram
+
+	labels := make(map[string]string, len(metric.Labels)+1)
+	for labelName, value := range metric.Labels {
+		labels[labelName] = value
+	}
+
+	for i, count := range h.Cumulative() {
+		le := "+Inf"
+		if i < len(h.Bounds) {
+			le = formatPrometheusValue(h.Bounds[i])
+		}
+		labels["le"] = le
+		fmt.Fprintf(buf, "%s_bucket%s %d\n", name, formatPrometheusLabels(labels), count)
+	}
+
+	fmt.Fprintf(buf, "%s_sum%s %s\n", name, formatPrometheusLabels(metric.Labels), formatPrometheusValue(h.Sum))
+	fmt.Fprintf(buf, "%s_count%s %d\n", name, formatPrometheusLabels(metric.Labels), h.Count)
+}
+
+// writePrometheusSummary выводит квантили сводки по умолчанию, её сумму и количество значений
+func writePrometheusSummary(buf *bytes.Buffer, name string, metric metrics.Metrics) {
+	if metric.Summary == nil {
+		return
+	}
+	sketch := metric.Summary
+
+	labels := make(map[string]string, len(metric.Labels)+1)
+	for labelName, value := range metric.Labels {
+		labels[labelName] = value
+	}
+
+	if sketch.Count > 0 {
+		for _, q := range metrics.DefaultQuantiles {
+			labels["quantile"] = formatPrometheusValue(q)
+			fmt.Fprintf(buf, "%s%s %s\n", name, formatPrometheusLabels(labels), formatPrometheusValue(sketch.Quantile(q)))
+		}
+	}
+
+	fmt.Fprintf(buf, "%s_sum%s %s\n", name, formatPrometheusLabels(metric.Labels), formatPrometheusValue(sketch.Sum))
+	fmt.Fprintf(buf, "%s_count%s %d\n", name, formatPrometheusLabels(metric.Labels), sketch.Count)
+}
+
+// SanitizePrometheusName заменяет недопустимые в Prometheus символы названия метрики на подчёркивание
+func SanitizePrometheusName(id string) string {
+	var sb strings.Builder
+	sb.Grow(len(id) + 1)
+	for i, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_', r == ':':
+			sb.WriteRune(r)
+		case r >= '0' && r <= '9':
+			if i == 0 {
+				sb.WriteByte('_')
+			}
+			sb.WriteRune(r)
+		default:
+			sb.WriteByte('_')
+		}
+	}
+
+	if sb.Len() == 0 {
+		return "_"
+	}
+
+	return sb.String()
+}
+
+func formatPrometheusLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		labelName := strings.ReplaceAll(SanitizePrometheusName(name), ":", "_")
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, labelName, escapePrometheusLabelValue(labels[name])))
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func escapePrometheusLabelValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+func escapePrometheusHelp(text string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(text)
+}
+
+func formatPrometheusValue(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/metrics"
+)
+
+func TestPrometheusMetricsHandler(t *testing.T) {
+	labelled := metrics.NewGauge("HeapAlloc", 4096).
+		WithLabels(map[string]string{"host": "web-1", "service.name": "a \"quoted\"\nvalue"})
+
+	latency := metrics.NewHistogram("Latency", []float64{0.1, 1})
+	latency.Histogram.Observe(0.05)
+	latency.Histogram.Observe(0.5)
+	latency.Histogram.Observe(3)
+
+	rtt, errSummary := metrics.NewSummary("RTT", 0.01)
+	require.NoError(t, errSummary)
+	rtt.Summary.Add(2)
+	rtt.Summary.Add(4)
+
+	ms := new(mockStorage)
+	ms.On("GetCollection").Return(map[string]metrics.Metrics{
+		"RTT":             *rtt,
+		"PollCount":       *metrics.NewCounter("PollCount", 15),
+		"HeapAlloc":       *metrics.NewGauge("HeapAlloc", 2048),
+		labelled.Key():    *labelled,
+		"GCCPUFraction":   *metrics.NewGauge("GCCPUFraction", 0.25),
+		"Latency":         *latency,
+		"1cpu.usage-idle": *metrics.NewGauge("1cpu.usage-idle", 12.5),
+	}, nil)
+
+	ser := NewServer("some_address", ms, "", "")
+
+	req, errReq := http.NewRequest(http.MethodGet, "/metrics", nil)
+	require.NoError(t, errReq)
+
+	w := httptest.NewRecorder()
+	ser.router.ServeHTTP(w, req)
+	res := w.Result()
+
+	defer res.Body.Close()
+	resBody, errReadBody := io.ReadAll(res.Body)
+	require.NoError(t, errReadBody)
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, prometheusContentType, res.Header.Get("Content-Type"))
+	assert.Equal(t, `# HELP GCCPUFraction Metric GCCPUFraction of type gauge
+# TYPE GCCPUFraction gauge
+GCCPUFraction 0.25
+# HELP HeapAlloc Metric HeapAlloc of type gauge
+# TYPE HeapAlloc gauge
+HeapAlloc 2048
+HeapAlloc{host="web-1",service_name="a \"quoted\"\nvalue"} 4096
+# HELP Latency Metric Latency of type histogram
+# TYPE Latency histogram
+Latency_bucket{le="0.1"} 1
+Latency_bucket{le="1"} 2
+Latency_bucket{le="+Inf"} 3
+Latency_sum 3.55
+Latency_count 3
+# HELP PollCount Metric PollCount of type counter
+# TYPE PollCount counter
+PollCount 15
+# HELP RTT Metric RTT of type summary
+# TYPE RTT summary
+RTT{quantile="0.5"} 2
+RTT{quantile="0.9"} 2
+RTT{quantile="0.99"} 2
+RTT_sum 6
+RTT_count 2
+# HELP _1cpu_usage_idle Metric 1cpu.usage-idle of type gauge
+# TYPE _1cpu_usage_idle gauge
+_1cpu_usage_idle 12.5
+`, string(resBody))
+}
+
+func TestSanitizePrometheusName(t *testing.T) {
+	tests := []struct {
+		id   string
+		want string
+	}{
+		{id: "HeapAlloc", want: "HeapAlloc"},
+		{id: "http:requests_total", want: "http:requests_total"},
+		{id: "disk.used-bytes", want: "disk_used_bytes"},
+		{id: "5xx", want: "_5xx"},
+		{id: "CPUutilization1", want: "CPUutilization1"},
+		{id: "температура", want: "___________"},
+		{id: "", want: "_"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			assert.Equal(t, tt.want, SanitizePrometheusName(tt.id))
+		})
+	}
+}
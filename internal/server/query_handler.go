@@ -0,0 +1,160 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/factory/storage"
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/metrics"
+)
+
+const (
+	defaultQueryRange = time.Hour
+	defaultQueryStep  = time.Minute
+	maxQueryPoints    = 11000
+)
+
+// QueryRangeResponse ответ на запрос истории значений метрики
+type QueryRangeResponse struct {
+	ID          string            `json:"id"`
+	Type        string            `json:"type"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Aggregation string            `json:"aggregation"`
+	Step        float64           `json:"step"` // Шаг в секундах
+	Points      []storage.Sample  `json:"points"`
+}
+
+// QueryRangeHandler godoc
+// @Tags Info
+// @Summary История значений метрики
+// @Description Возвращает значения метрики за промежуток, агрегированные по шагам
+// @Produce json
+// @Param id query string true "Название метрики"
+// @Param type query string true "Тип метрики"
+// @Param from query string false "Начало промежутка (unix time или RFC3339), по умолчанию час назад"
+// @Param to query string false "Конец промежутка (unix time или RFC3339), по умолчанию сейчас"
+// @Param step query string false "Шаг (длительность или секунды), по умолчанию 1m"
+// @Param agg query string false "Агрегация: avg, min, max, sum, last, rate. По умолчанию avg, для rate нужен counter"
+// @Param label query string false "Остальные параметры считаются метками метрики, например, host=web-1"
+// @Success 200 {object} QueryRangeResponse
+// @Failure 400 {object} JSONObj
+// @Failure 404 {object} JSONObj
+// @Failure 501 {object} JSONObj
+// @Router /api/v1/query_range [get]
+func (s *Server) QueryRangeHandler(rw http.ResponseWriter, r *http.Request) {
+	rangeGetter, ok := s.storage.(storage.RangeGetter)
+	if !ok {
+		JSON(rw, http.StatusNotImplemented, JSONObj{"message": "History of metric values is not enabled"})
+		return
+	}
+
+	query := r.URL.Query()
+
+	metric := metrics.New(query.Get("type"), query.Get("id")).
+		WithLabels(LabelsFromQuery(r, "id", "type", "from", "to", "step", "agg"))
+	if metric.ID == "" {
+		JSON(rw, http.StatusBadRequest, JSONObj{"message": "No metric ID specified"})
+		return
+	}
+	if metric.Type != metrics.StringCounterType && metric.Type != metrics.StringGaugeType {
+		JSON(rw, http.StatusBadRequest, JSONObj{"message": "Invalid metric type"})
+		return
+	}
+
+	now := time.Now()
+	to, err := parseQueryTime(query.Get("to"), now)
+	if err != nil {
+		JSON(rw, http.StatusBadRequest, JSONObj{"message": fmt.Sprintf("Invalid to: %s", err)})
+		return
+	}
+	from, err := parseQueryTime(query.Get("from"), to.Add(-defaultQueryRange))
+	if err != nil {
+		JSON(rw, http.StatusBadRequest, JSONObj{"message": fmt.Sprintf("Invalid from: %s", err)})
+		return
+	}
+	step, err := parseQueryStep(query.Get("step"))
+	if err != nil {
+		JSON(rw, http.StatusBadRequest, JSONObj{"message": fmt.Sprintf("Invalid step: %s", err)})
+		return
+	}
+	if to.Before(from) {
+		JSON(rw, http.StatusBadRequest, JSONObj{"message": "The end of the range is before its start"})
+		return
+	}
+	if to.Sub(from)/step > maxQueryPoints {
+		JSON(rw, http.StatusBadRequest, JSONObj{"message": fmt.Sprintf("Too many points, the maximum is %d", maxQueryPoints)})
+		return
+	}
+
+	aggregation := query.Get("agg")
+	if aggregation == "" {
+		aggregation = storage.AggregationAvg
+	}
+	if aggregation == storage.AggregationRate && metric.Type != metrics.StringCounterType {
+		JSON(rw, http.StatusBadRequest, JSONObj{"message": "Rate is only supported for counters"})
+		return
+	}
+
+	samples, err := rangeGetter.GetRange(*metric, from, to)
+	if err != nil {
+		if errors.Is(err, metrics.ErrNoValue) {
+			JSON(rw, http.StatusNotFound, JSONObj{"message": "No value"})
+			return
+		}
+		JSON(rw, http.StatusInternalServerError, JSONObj{"message": err.Error()})
+		return
+	}
+
+	points, err := storage.Downsample(samples, from, to, step, aggregation)
+	if err != nil {
+		JSON(rw, http.StatusBadRequest, JSONObj{"message": err.Error()})
+		return
+	}
+
+	JSON(rw, http.StatusOK, QueryRangeResponse{
+		ID:          metric.ID,
+		Type:        metric.Type,
+		Labels:      metric.Labels,
+		Aggregation: aggregation,
+		Step:        step.Seconds(),
+		Points:      points,
+	})
+}
+
+// parseQueryTime разбирает время в формате unix time (в секундах, можно дробных) или RFC3339
+func parseQueryTime(value string, fallback time.Time) (time.Time, error) {
+	if value == "" {
+		return fallback, nil
+	}
+
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		whole, fraction := math.Modf(seconds)
+		return time.Unix(int64(whole), int64(fraction*float64(time.Second))), nil
+	}
+
+	return time.Parse(time.RFC3339, value)
+}
+
+// parseQueryStep разбирает шаг в формате длительности (30s, 1m) или количества секунд
+func parseQueryStep(value string) (time.Duration, error) {
+	if value == "" {
+		return defaultQueryStep, nil
+	}
+
+	var step time.Duration
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		step = time.Duration(seconds * float64(time.Second))
+	} else if step, err = time.ParseDuration(value); err != nil {
+		return 0, err
+	}
+
+	if step <= 0 {
+		return 0, errors.New("step must be positive")
+	}
+
+	return step, nil
+}
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/factory/storage"
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/metrics"
+)
+
+type mockRangeStorage struct {
+	mockStorage
+}
+
+func (m *mockRangeStorage) GetRange(metric metrics.Metrics, from time.Time, to time.Time) ([]storage.Sample, error) {
+	args := m.Called(metric, from, to)
+	return args.Get(0).([]storage.Sample), args.Error(1)
+}
+
+func TestQueryRangeHandler(t *testing.T) {
+	start := time.Unix(1664625600, 0)
+	samples := []storage.Sample{
+		{Timestamp: start, Value: 1},
+		{Timestamp: start.Add(10 * time.Second), Value: 3},
+		{Timestamp: start.Add(70 * time.Second), Value: 5},
+	}
+
+	type want struct {
+		code     int
+		response any
+	}
+	tests := []struct {
+		name    string
+		uri     string
+		samples []storage.Sample
+		err     error
+		want    want
+	}{
+		{
+			name:    "OK",
+			uri:     "/api/v1/query_range?id=Alloc&type=gauge&from=1664625600&to=1664625719&step=60&agg=max",
+			samples: samples,
+			want: want{
+				code: 200,
+				response: QueryRangeResponse{
+					ID:          "Alloc",
+					Type:        metrics.StringGaugeType,
+					Aggregation: storage.AggregationMax,
+					Step:        60,
+					Points: []storage.Sample{
+						{Timestamp: start, Value: 3},
+						{Timestamp: start.Add(time.Minute), Value: 5},
+					},
+				},
+			},
+		},
+		{
+			name:    "RFC3339 and duration step",
+			uri:     "/api/v1/query_range?id=Alloc&type=gauge&from=" + start.UTC().Format(time.RFC3339) + "&to=1664625719&step=2m",
+			samples: samples,
+			want: want{
+				code: 200,
+				response: QueryRangeResponse{
+					ID:          "Alloc",
+					Type:        metrics.StringGaugeType,
+					Aggregation: storage.AggregationAvg,
+					Step:        120,
+					Points:      []storage.Sample{{Timestamp: start, Value: 3}},
+				},
+			},
+		},
+		{
+			name: "No metric ID specified",
+			uri:  "/api/v1/query_range?type=gauge",
+			want: want{code: 400, response: JSONObj{"message": "No metric ID specified"}},
+		},
+		{
+			name: "Rate for gauge",
+			uri:  "/api/v1/query_range?id=Alloc&type=gauge&agg=rate",
+			want: want{code: 400, response: JSONObj{"message": "Rate is only supported for counters"}},
+		},
+		{
+			name: "Too many points",
+			uri:  "/api/v1/query_range?id=Alloc&type=gauge&from=0&to=1664625600&step=1s",
+			want: want{code: 400, response: JSONObj{"message": "Too many points, the maximum is 11000"}},
+		},
+		{
+			name:    "No value for that metric",
+			uri:     "/api/v1/query_range?id=Alloc&type=gauge",
+			samples: []storage.Sample{},
+			err:     metrics.ErrNoValue,
+			want:    want{code: 404, response: JSONObj{"message": "No value"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms := new(mockRangeStorage)
+			ms.On("GetRange", mock.Anything, mock.Anything, mock.Anything).Return(tt.samples, tt.err)
+
+			ser := NewServer("some_address", ms, "", "")
+
+			req, errReq := http.NewRequest(http.MethodGet, tt.uri, nil)
+			require.NoError(t, errReq)
+
+			w := httptest.NewRecorder()
+			ser.router.ServeHTTP(w, req)
+			res := w.Result()
+
+			defer res.Body.Close()
+			resBody, errReadBody := io.ReadAll(res.Body)
+			require.NoError(t, errReadBody)
+
+			assert.Equal(t, tt.want.code, res.StatusCode)
+			switch want := tt.want.response.(type) {
+			case QueryRangeResponse:
+				var jsonRes QueryRangeResponse
+				require.NoError(t, json.Unmarshal(resBody, &jsonRes))
+				require.Len(t, jsonRes.Points, len(want.Points))
+				for i := range want.Points {
+					assert.True(t, want.Points[i].Timestamp.Equal(jsonRes.Points[i].Timestamp))
+					assert.Equal(t, want.Points[i].Value, jsonRes.Points[i].Value)
+				}
+				jsonRes.Points, want.Points = nil, nil
+				assert.Equal(t, want, jsonRes)
+			case JSONObj:
+				var jsonRes JSONObj
+				require.NoError(t, json.Unmarshal(resBody, &jsonRes))
+				assert.Equal(t, want, jsonRes)
+			}
+		})
+	}
+}
+
+func TestQueryRangeHandler_NoHistory(t *testing.T) {
+	ser := NewServer("some_address", new(mockStorage), "", "")
+
+	req, errReq := http.NewRequest(http.MethodGet, "/api/v1/query_range?id=Alloc&type=gauge", nil)
+	require.NoError(t, errReq)
+
+	w := httptest.NewRecorder()
+	ser.router.ServeHTTP(w, req)
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusNotImplemented, res.StatusCode)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	"net/http/pprof"
+	"time"
 
 	"github.com/PostScripton/go-metrics-and-alerting-collection/pkg/key_management/rsakeys"
 	"github.com/go-chi/chi/v5"
@@ -19,6 +20,7 @@ type Server struct {
 	router  *chi.Mux
 	storage storage.Storager
 	key     string
+	tracker storage.UpdateTracker
 }
 
 func NewServer(address string, storage storage.Storager, key string, cryptoKey string) *Server {
@@ -47,6 +49,18 @@ func NewServer(address string, storage storage.Storager, key string, cryptoKey s
 	return s
 }
 
+// SetUpdateTracker позволяет отслеживать время последнего обновления каждой метрики
+func (s *Server) SetUpdateTracker(tracker storage.UpdateTracker) {
+	s.tracker = tracker
+}
+
+func (s *Server) touch(ids ...string) {
+	if s.tracker == nil {
+		return
+	}
+	s.tracker.Touch(time.Now(), ids...)
+}
+
 func (s *Server) registerRoutes() {
 	s.router.NotFound(NotFound)
 	s.router.MethodNotAllowed(MethodNotAllowed)
@@ -62,6 +76,9 @@ func (s *Server) registerRoutes() {
 	s.router.Post("/value", s.GetMetricJSONHandler)
 	s.router.Post("/update", s.UpdateMetricJSONHandler)
 	s.router.Post("/updates", s.UpdateMetricsBatchJSONHandler)
+
+	s.router.Get("/api/v1/query_range", s.QueryRangeHandler)
+	s.router.Get("/metrics", s.PrometheusMetricsHandler)
 }
 
 func (s *Server) Run() error {
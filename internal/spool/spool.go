@@ -0,0 +1,244 @@
+// Package spool хранит на диске пачки метрик, которые не удалось отправить на сервер,
+// чтобы переотправить их в том же порядке, когда сервер снова станет доступен.
+package spool
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/metrics"
+)
+
+const batchExt = ".batch.json"
+const tmpExt = ".tmp"
+
+// ErrBatchTooLarge пачка сама по себе больше допустимого размера очереди
+var ErrBatchTooLarge = errors.New("batch is larger than the spool size limit")
+
+// Spool очередь пачек метрик в директории на диске. Каждая пачка хранится в отдельном файле,
+// имя файла — порядковый номер, поэтому после перезапуска порядок сохраняется.
+// При превышении maxSize удаляются самые старые пачки.
+type Spool struct {
+	mu      sync.Mutex
+	dir     string
+	maxSize int64
+	seq     uint64
+	files   []spoolFile
+	size    int64
+}
+
+type spoolFile struct {
+	seq  uint64
+	size int64
+}
+
+// New открывает очередь в директории dir, создавая её при необходимости. maxSize <= 0 означает без ограничения
+func New(dir string, maxSize int64) (*Spool, error) {
+	if dir == "" {
+		return nil, errors.New("empty spool directory")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("creating spool directory: %w", err)
+	}
+
+	s := &Spool{
+		dir:     dir,
+		maxSize: maxSize,
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *Spool) load() error {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return fmt.Errorf("reading spool directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() {
+			continue
+		}
+		if strings.HasSuffix(name, tmpExt) {
+			// недописанный файл после аварийного завершения
+			_ = os.Remove(filepath.Join(s.dir, name))
+			continue
+		}
+		if !strings.HasSuffix(name, batchExt) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, batchExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		s.files = append(s.files, spoolFile{seq: seq, size: info.Size()})
+		s.size += info.Size()
+	}
+
+	sort.Slice(s.files, func(i, j int) bool {
+		return s.files[i].seq < s.files[j].seq
+	})
+	if len(s.files) > 0 {
+		s.seq = s.files[len(s.files)-1].seq
+	}
+
+	return nil
+}
+
+// Push записывает пачку в конец очереди
+func (s *Spool) Push(batch map[string]metrics.Metrics) error {
+	data, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+	size := int64(len(data))
+	if s.maxSize > 0 && size > s.maxSize {
+		return ErrBatchTooLarge
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seq := s.seq + 1
+	if err = s.write(seq, data); err != nil {
+		return err
+	}
+	s.seq = seq
+	s.files = append(s.files, spoolFile{seq: seq, size: size})
+	s.size += size
+
+	for s.maxSize > 0 && s.size > s.maxSize && len(s.files) > 1 {
+		oldest := s.files[0]
+		if err = s.remove(oldest); err != nil {
+			return err
+		}
+		log.Warn().Uint64("seq", oldest.seq).Msg("Spool is full, the oldest batch was dropped")
+	}
+
+	return nil
+}
+
+// Replay отправляет пачки из очереди по порядку, удаляя каждую после успешной отправки.
+// Пачка, ошибку отправки которой rejected считает окончательной, удаляется, и отправка продолжается.
+// На остальных ошибках останавливается, оставляя неотправленные пачки в очереди
+func (s *Spool) Replay(send func(batch map[string]metrics.Metrics) error, rejected func(err error) bool) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sent := 0
+	for len(s.files) > 0 {
+		file := s.files[0]
+		batch, err := s.read(file.seq)
+		if err != nil {
+			log.Warn().Err(err).Uint64("seq", file.seq).Msg("Dropping unreadable spooled batch")
+			if err = s.remove(file); err != nil {
+				return sent, err
+			}
+			continue
+		}
+
+		if err = send(batch); err != nil {
+			if !rejected(err) {
+				return sent, err
+			}
+			log.Error().Err(err).Uint64("seq", file.seq).Msg("Dropping spooled batch rejected by the server")
+			if err = s.remove(file); err != nil {
+				return sent, err
+			}
+			continue
+		}
+		if err = s.remove(file); err != nil {
+			return sent, err
+		}
+		sent++
+	}
+
+	return sent, nil
+}
+
+// Len возвращает количество пачек в очереди
+func (s *Spool) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.files)
+}
+
+// Size возвращает суммарный размер пачек в очереди в байтах
+func (s *Spool) Size() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.size
+}
+
+func (s *Spool) path(seq uint64) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%020d%s", seq, batchExt))
+}
+
+// write пишет во временный файл и переименовывает его, чтобы в очереди не оказалось недописанной пачки
+func (s *Spool) write(seq uint64, data []byte) error {
+	path := s.path(seq)
+	tmp := path + tmpExt
+
+	file, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err = file.Write(data); err != nil {
+		_ = file.Close()
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err = file.Sync(); err != nil {
+		_ = file.Close()
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err = file.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+func (s *Spool) read(seq uint64) (map[string]metrics.Metrics, error) {
+	data, err := os.ReadFile(s.path(seq))
+	if err != nil {
+		return nil, err
+	}
+
+	batch := map[string]metrics.Metrics{}
+	if err = json.Unmarshal(data, &batch); err != nil {
+		return nil, err
+	}
+
+	return batch, nil
+}
+
+func (s *Spool) remove(file spoolFile) error {
+	if err := os.Remove(s.path(file.seq)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	s.files = s.files[1:]
+	s.size -= file.size
+	return nil
+}
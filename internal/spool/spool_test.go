@@ -0,0 +1,145 @@
+package spool
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/metrics"
+)
+
+func batchOf(id string, delta int64) map[string]metrics.Metrics {
+	return map[string]metrics.Metrics{id: *metrics.NewCounter(id, delta)}
+}
+
+func neverRejected(error) bool {
+	return false
+}
+
+func TestSpool_ReplayInOrderAfterReopen(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := New(dir, 0)
+	require.NoError(t, err)
+	require.NoError(t, s.Push(batchOf("First", 1)))
+	require.NoError(t, s.Push(batchOf("Second", 2)))
+	require.NoError(t, s.Push(batchOf("Third", 3)))
+
+	// недописанный файл от аварийного завершения должен быть удалён
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "00000000000000000009"+batchExt+tmpExt), []byte("{"), 0644))
+
+	reopened, err := New(dir, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 3, reopened.Len())
+	assert.Equal(t, s.Size(), reopened.Size())
+
+	var order []string
+	sent, err := reopened.Replay(func(batch map[string]metrics.Metrics) error {
+		for id := range batch {
+			order = append(order, id)
+		}
+		return nil
+	}, neverRejected)
+	require.NoError(t, err)
+	assert.Equal(t, 3, sent)
+	assert.Equal(t, []string{"First", "Second", "Third"}, order)
+	assert.Equal(t, 0, reopened.Len())
+	assert.Equal(t, int64(0), reopened.Size())
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestSpool_ReplayStopsOnError(t *testing.T) {
+	s, err := New(t.TempDir(), 0)
+	require.NoError(t, err)
+	require.NoError(t, s.Push(batchOf("First", 1)))
+	require.NoError(t, s.Push(batchOf("Second", 2)))
+
+	errDown := errors.New("server is down")
+	calls := 0
+	sent, err := s.Replay(func(batch map[string]metrics.Metrics) error {
+		calls++
+		if _, ok := batch["Second"]; ok {
+			return errDown
+		}
+		return nil
+	}, neverRejected)
+	assert.ErrorIs(t, err, errDown)
+	assert.Equal(t, 1, sent)
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, 1, s.Len())
+
+	// следующая пачка, записанная после частичной отправки, не должна обогнать оставшуюся
+	require.NoError(t, s.Push(batchOf("Third", 3)))
+	var order []string
+	_, err = s.Replay(func(batch map[string]metrics.Metrics) error {
+		for id := range batch {
+			order = append(order, id)
+		}
+		return nil
+	}, neverRejected)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Second", "Third"}, order)
+}
+
+func TestSpool_ReplaySkipsRejected(t *testing.T) {
+	s, err := New(t.TempDir(), 0)
+	require.NoError(t, err)
+	require.NoError(t, s.Push(batchOf("First", 1)))
+	require.NoError(t, s.Push(batchOf("Second", 2)))
+	require.NoError(t, s.Push(batchOf("Third", 3)))
+
+	errRejected := errors.New("signature does not match")
+	var order []string
+	sent, err := s.Replay(func(batch map[string]metrics.Metrics) error {
+		if _, ok := batch["First"]; ok {
+			return errRejected
+		}
+		for id := range batch {
+			order = append(order, id)
+		}
+		return nil
+	}, func(err error) bool {
+		return errors.Is(err, errRejected)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, sent)
+	assert.Equal(t, []string{"Second", "Third"}, order, "a rejected batch must not block the queue")
+	assert.Equal(t, 0, s.Len())
+}
+
+func TestSpool_SizeLimitDropsOldest(t *testing.T) {
+	dir := t.TempDir()
+	probe, err := New(filepath.Join(dir, "probe"), 0)
+	require.NoError(t, err)
+	require.NoError(t, probe.Push(batchOf("Batch1", 1)))
+	batchSize := probe.Size()
+
+	s, err := New(filepath.Join(dir, "spool"), 2*batchSize)
+	require.NoError(t, err)
+	for _, id := range []string{"Batch1", "Batch2", "Batch3"} {
+		require.NoError(t, s.Push(batchOf(id, 1)))
+	}
+	assert.Equal(t, 2, s.Len())
+	assert.LessOrEqual(t, s.Size(), 2*batchSize)
+
+	var order []string
+	_, err = s.Replay(func(batch map[string]metrics.Metrics) error {
+		for id := range batch {
+			order = append(order, id)
+		}
+		return nil
+	}, neverRejected)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Batch2", "Batch3"}, order)
+
+	tiny, err := New(filepath.Join(dir, "tiny"), 10)
+	require.NoError(t, err)
+	assert.ErrorIs(t, tiny.Push(batchOf("Batch1", 1)), ErrBatchTooLarge)
+}
@@ -0,0 +1,181 @@
+package statsd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/factory/storage"
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/linelistener"
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/metrics"
+)
+
+// maxPacketSize максимальный размер UDP-пакета и строки TCP-потока
+const maxPacketSize = 64 * 1024
+
+// DefaultBuckets границы корзин гистограмм таймингов по умолчанию в миллисекундах
+var DefaultBuckets = []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000}
+
+// Handler переводит строки StatsD в метрики и сохраняет их. Счётчики учитывают долю отправленных значений,
+// датчики со знаком изменяют последнее полученное значение, а тайминги попадают в гистограмму или сводку
+type Handler struct {
+	store           storage.Storer
+	buckets         []float64
+	summaryAccuracy float64 // Точность сводки для таймингов, 0 — тайминги попадают в гистограмму
+	tracker         storage.UpdateTracker
+
+	mu     sync.Mutex
+	gauges map[string]float64 // Последние значения датчиков для изменений со знаком
+}
+
+func NewHandler(store storage.Storer, buckets []float64) *Handler {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+
+	return &Handler{
+		store:   store,
+		buckets: buckets,
+		gauges:  make(map[string]float64),
+	}
+}
+
+// SetSummary сохраняет тайминги в сводку с заданной относительной точностью вместо гистограммы
+func (h *Handler) SetSummary(relativeAccuracy float64) error {
+	if _, err := metrics.NewSummary("", relativeAccuracy); err != nil {
+		return err
+	}
+	h.summaryAccuracy = relativeAccuracy
+	return nil
+}
+
+// SetUpdateTracker позволяет отслеживать время последнего обновления каждой метрики
+func (h *Handler) SetUpdateTracker(tracker storage.UpdateTracker) {
+	h.tracker = tracker
+}
+
+// Handle разбирает пакет из строк, разделённых переводом строки. Некорректные строки пропускаются,
+// а ошибка содержит их количество и первую из них
+func (h *Handler) Handle(packet []byte) error {
+	var failed int
+	var firstErr error
+	for _, raw := range bytes.Split(packet, []byte("\n")) {
+		raw = bytes.TrimSpace(raw)
+		if len(raw) == 0 {
+			continue
+		}
+
+		line, err := ParseLine(string(raw))
+		if err == nil {
+			err = h.Store(line)
+		}
+		if err != nil {
+			failed++
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d invalid lines: %w", failed, firstErr)
+	}
+	return nil
+}
+
+// Store сохраняет разобранную строку в хранилище
+func (h *Handler) Store(line Line) error {
+	var metric *metrics.Metrics
+	switch line.Type {
+	case TypeCounter:
+		// каждое полученное значение представляет 1/rate отправленных
+		delta := math.Round(line.Value / line.SampleRate)
+		if math.IsNaN(delta) || delta >= math.MaxInt64 || delta <= math.MinInt64 {
+			return fmt.Errorf("counter %q value %v at sample rate %v is out of range", line.Name, line.Value, line.SampleRate)
+		}
+		metric = metrics.NewCounter(line.Name, int64(delta)).WithLabels(line.Tags)
+	case TypeGauge:
+		metric = metrics.NewGauge(line.Name, line.Value).WithLabels(line.Tags)
+		h.mu.Lock()
+		key := metric.Key()
+		if line.Relative {
+			*metric.Value += h.gauges[key]
+		}
+		h.gauges[key] = *metric.Value
+		h.mu.Unlock()
+	case TypeTiming, TypeHistogram:
+		var observe func(value float64, count uint64)
+		metric, observe = h.timing(line.Name)
+		metric.WithLabels(line.Tags)
+		// значение учитывается с весом 1/rate, доля ограничена снизу MinSampleRate
+		observe(line.Value, uint64(math.Max(1, math.Round(1/line.SampleRate))))
+	default:
+		return fmt.Errorf("metric %q has an unsupported type %q", line.Name, line.Type)
+	}
+
+	if err := h.store.Store(*metric); err != nil {
+		return err
+	}
+	if h.tracker != nil {
+		h.tracker.Touch(time.Now(), metric.Key())
+	}
+	return nil
+}
+
+// timing создаёт пустую гистограмму или сводку для тайминга и функцию добавления значения в неё
+func (h *Handler) timing(name string) (*metrics.Metrics, func(value float64, count uint64)) {
+	if h.summaryAccuracy > 0 {
+		// точность проверена в SetSummary
+		metric, _ := metrics.NewSummary(name, h.summaryAccuracy)
+		return metric, metric.Summary.AddN
+	}
+	metric := metrics.NewHistogram(name, h.buckets)
+	return metric, metric.Histogram.ObserveN
+}
+
+// ServeUDP читает пакеты из conn, пока не завершится контекст или не закроется conn.
+// После остальных ошибок чтение повторяется с экспоненциальной задержкой
+func (h *Handler) ServeUDP(ctx context.Context, conn net.PacketConn) error {
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	var backoff linelistener.Backoff
+	buf := make([]byte, maxPacketSize)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			log.Warn().Err(err).Msg("Reading StatsD packet")
+			if !backoff.Wait(ctx) {
+				return nil
+			}
+			continue
+		}
+		backoff.Reset()
+
+		if err = h.Handle(buf[:n]); err != nil {
+			log.Warn().Err(err).Str("from", addr.String()).Msg("Parsing StatsD packet")
+		}
+	}
+}
+
+// ServeTCP принимает соединения из listener и читает из каждого строки, пока не завершится контекст.
+// При завершении контекста открытые соединения закрываются
+func (h *Handler) ServeTCP(ctx context.Context, listener net.Listener) error {
+	return linelistener.Serve(ctx, listener, maxPacketSize, func(line []byte, from net.Addr) {
+		if err := h.Handle(line); err != nil {
+			log.Warn().Err(err).Str("from", from.String()).Msg("Parsing StatsD line")
+		}
+	})
+}
@@ -0,0 +1,181 @@
+package statsd
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/factory/storage/memory"
+	"github.com/PostScripton/go-metrics-and-alerting-collection/internal/metrics"
+)
+
+type touchedIDs []string
+
+func (t *touchedIDs) Touch(_ time.Time, ids ...string) {
+	*t = append(*t, ids...)
+}
+
+func TestHandler_Handle(t *testing.T) {
+	store := memory.NewMemoryStorage()
+	h := NewHandler(store, []float64{10, 100})
+	var touched touchedIDs
+	h.SetUpdateTracker(&touched)
+
+	err := h.Handle([]byte("app.requests:1|c\napp.requests:1|c|@0.5\n\nqueue:10|g\nqueue:-3|g\nqueue:+1|g\ndb:5|ms|@0.5\ndb:50|ms\nbroken\nusers:1|s"))
+	assert.EqualError(t, err, `2 invalid lines: expected "name:value|type", got "broken"`)
+
+	collection, err := store.GetCollection()
+	require.NoError(t, err)
+
+	histogram := metrics.NewHistogram("db", []float64{10, 100})
+	histogram.Histogram.Observe(5)
+	histogram.Histogram.Observe(5)
+	histogram.Histogram.Observe(50)
+
+	assert.Equal(t, map[string]metrics.Metrics{
+		"app.requests": *metrics.NewCounter("app.requests", 3),
+		"queue":        *metrics.NewGauge("queue", 8),
+		"db":           *histogram,
+	}, collection)
+	assert.Equal(t, touchedIDs{"app.requests", "app.requests", "queue", "queue", "queue", "db", "db"}, touched)
+}
+
+func TestHandler_StoreSampleRate(t *testing.T) {
+	store := memory.NewMemoryStorage()
+	h := NewHandler(store, []float64{10, 100})
+
+	started := time.Now()
+	require.NoError(t, h.Handle([]byte("db:5|ms|@0.000001")))
+	assert.Less(t, time.Since(started), 100*time.Millisecond, "a timing is recorded once with a weight")
+
+	metric, err := store.Get(metrics.Metrics{ID: "db", Type: metrics.StringHistogramType})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1_000_000), metric.Histogram.Count)
+	assert.Equal(t, []uint64{1_000_000, 0, 0}, metric.Histogram.Counts)
+
+	err = h.Handle([]byte("app.requests:1e300|c|@0.5\napp.requests:-1e19|c"))
+	assert.EqualError(t, err, `2 invalid lines: counter "app.requests" value 1e+300 at sample rate 0.5 is out of range`)
+	_, err = store.Get(metrics.Metrics{ID: "app.requests", Type: metrics.StringCounterType})
+	assert.ErrorIs(t, err, metrics.ErrNoValue)
+}
+
+func TestHandler_ServeUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	store := memory.NewMemoryStorage()
+	h := NewHandler(store, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- h.ServeUDP(ctx, conn)
+	}()
+
+	client, err := net.Dial("udp", conn.LocalAddr().String())
+	require.NoError(t, err)
+	defer client.Close()
+	_, err = client.Write([]byte("app.requests:5|c|#route:/login"))
+	require.NoError(t, err)
+
+	want := *metrics.NewCounter("app.requests", 5).WithLabels(map[string]string{"route": "/login"})
+	assert.Eventually(t, func() bool {
+		metric, err := store.Get(want)
+		return err == nil && *metric.Delta == 5
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+// flakyPacketConn возвращает ошибки чтения, пока не исчерпает failures
+type flakyPacketConn struct {
+	net.PacketConn
+	failures int
+}
+
+func (c *flakyPacketConn) ReadFrom(p []byte) (int, net.Addr, error) {
+	if c.failures > 0 {
+		c.failures--
+		return 0, nil, errors.New("read: connection refused")
+	}
+	return c.PacketConn.ReadFrom(p)
+}
+
+func TestHandler_ServeUDPAfterReadErrors(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	store := memory.NewMemoryStorage()
+	h := NewHandler(store, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- h.ServeUDP(ctx, &flakyPacketConn{PacketConn: conn, failures: 3})
+	}()
+
+	client, err := net.Dial("udp", conn.LocalAddr().String())
+	require.NoError(t, err)
+	defer client.Close()
+	_, err = client.Write([]byte("queue:7|g"))
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		metric, err := store.Get(*metrics.New(metrics.StringGaugeType, "queue"))
+		return err == nil && *metric.Value == 7
+	}, time.Second, 10*time.Millisecond, "packets are read after temporary errors")
+
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestHandler_SetSummary(t *testing.T) {
+	store := memory.NewMemoryStorage()
+	h := NewHandler(store, nil)
+	assert.Error(t, h.SetSummary(2))
+	require.NoError(t, h.SetSummary(0.01))
+
+	require.NoError(t, h.Handle([]byte("db:10|ms\ndb:20|ms|@0.5")))
+
+	metric, err := store.Get(metrics.Metrics{ID: "db", Type: metrics.StringSummaryType})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), metric.Summary.Count)
+	assert.InDelta(t, 20, metric.Summary.Quantile(0.5), 0.2)
+}
+
+func TestHandler_ServeTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	store := memory.NewMemoryStorage()
+	h := NewHandler(store, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- h.ServeTCP(ctx, listener)
+	}()
+
+	client, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	defer client.Close()
+	_, err = client.Write([]byte("queue:7|g\r\nbroken\napp.requests:2|c\n"))
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		collection, err := store.GetCollection()
+		return err == nil && len(collection) == 2
+	}, time.Second, 10*time.Millisecond)
+	collection, err := store.GetCollection()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]metrics.Metrics{
+		"queue":        *metrics.NewGauge("queue", 7),
+		"app.requests": *metrics.NewCounter("app.requests", 2),
+	}, collection)
+
+	cancel()
+	assert.NoError(t, <-done, "open connections are closed on shutdown")
+}
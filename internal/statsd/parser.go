@@ -0,0 +1,95 @@
+// Package statsd принимает метрики в строковом протоколе StatsD и сохраняет их в хранилище.
+package statsd
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Типы метрик StatsD
+const (
+	TypeCounter   = "c"
+	TypeGauge     = "g"
+	TypeTiming    = "ms"
+	TypeHistogram = "h"
+)
+
+// MinSampleRate наименьшая принимаемая доля отправленных значений. Одна строка с меньшей долей
+// представляла бы неправдоподобно много значений
+const MinSampleRate = 1e-6
+
+// Line разобранная строка вида name:value|type[|@rate][|#tag:value,...]
+type Line struct {
+	Name       string
+	Type       string
+	Value      float64
+	SampleRate float64           // Доля отправленных значений, от 0 до 1
+	Relative   bool              // Значение датчика со знаком изменяет его, а не задаёт
+	Tags       map[string]string // Теги в формате DogStatsD
+}
+
+// ParseLine разбирает одну строку протокола
+func ParseLine(raw string) (Line, error) {
+	line := Line{SampleRate: 1}
+
+	name, rest, found := strings.Cut(raw, ":")
+	if !found || name == "" {
+		return line, fmt.Errorf("expected \"name:value|type\", got %q", raw)
+	}
+	line.Name = name
+
+	parts := strings.Split(rest, "|")
+	if len(parts) < 2 {
+		return line, fmt.Errorf("metric %q has no type", name)
+	}
+
+	line.Type = parts[1]
+	switch line.Type {
+	case TypeCounter, TypeGauge, TypeTiming, TypeHistogram:
+	default:
+		return line, fmt.Errorf("metric %q has an unsupported type %q", name, line.Type)
+	}
+
+	value, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		return line, fmt.Errorf("metric %q has an invalid value %q", name, parts[0])
+	}
+	line.Value = value
+	line.Relative = line.Type == TypeGauge && (strings.HasPrefix(parts[0], "+") || strings.HasPrefix(parts[0], "-"))
+
+	for _, part := range parts[2:] {
+		switch {
+		case strings.HasPrefix(part, "@"):
+			rate, err := strconv.ParseFloat(part[1:], 64)
+			if err != nil || rate < MinSampleRate || rate > 1 {
+				return line, fmt.Errorf("metric %q has an invalid sample rate %q", name, part)
+			}
+			line.SampleRate = rate
+		case strings.HasPrefix(part, "#"):
+			tags, err := parseTags(part[1:])
+			if err != nil {
+				return line, fmt.Errorf("metric %q: %w", name, err)
+			}
+			line.Tags = tags
+		default:
+			return line, fmt.Errorf("metric %q has an unknown field %q", name, part)
+		}
+	}
+
+	return line, nil
+}
+
+func parseTags(s string) (map[string]string, error) {
+	tags := make(map[string]string)
+	for _, tag := range strings.Split(s, ",") {
+		name, value, _ := strings.Cut(tag, ":")
+		if name == "" {
+			return nil, errors.New("empty tag name")
+		}
+		tags[name] = value
+	}
+	return tags, nil
+}
@@ -0,0 +1,63 @@
+package statsd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    Line
+		wantErr bool
+	}{
+		{
+			name: "Counter",
+			raw:  "app.requests:1|c",
+			want: Line{Name: "app.requests", Type: TypeCounter, Value: 1, SampleRate: 1},
+		},
+		{
+			name: "Counter with sample rate and tags",
+			raw:  "app.requests:2|c|@0.1|#route:/login,canary",
+			want: Line{Name: "app.requests", Type: TypeCounter, Value: 2, SampleRate: 0.1, Tags: map[string]string{"route": "/login", "canary": ""}},
+		},
+		{
+			name: "Gauge",
+			raw:  "queue.depth:42|g",
+			want: Line{Name: "queue.depth", Type: TypeGauge, Value: 42, SampleRate: 1},
+		},
+		{
+			name: "Relative gauge",
+			raw:  "queue.depth:-3|g",
+			want: Line{Name: "queue.depth", Type: TypeGauge, Value: -3, SampleRate: 1, Relative: true},
+		},
+		{
+			name: "Timing",
+			raw:  "db.query:12.5|ms",
+			want: Line{Name: "db.query", Type: TypeTiming, Value: 12.5, SampleRate: 1},
+		},
+		{name: "No value", raw: "app.requests", wantErr: true},
+		{name: "No type", raw: "app.requests:1", wantErr: true},
+		{name: "Set is unsupported", raw: "users:42|s", wantErr: true},
+		{name: "Invalid value", raw: "app.requests:one|c", wantErr: true},
+		{name: "Non-finite value", raw: "app.requests:NaN|c", wantErr: true},
+		{name: "Infinite value", raw: "db:+Inf|ms", wantErr: true},
+		{name: "Invalid sample rate", raw: "app.requests:1|c|@2", wantErr: true},
+		{name: "Too small sample rate", raw: "app.requests:1|c|@0.0000001", wantErr: true},
+		{name: "Unknown field", raw: "app.requests:1|c|x", wantErr: true},
+		{name: "Empty tag name", raw: "app.requests:1|c|#:x", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line, err := ParseLine(tt.raw)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, line)
+		})
+	}
+}
@@ -1,8 +1,11 @@
 package types
 
 import (
+	"encoding"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 )
 
@@ -43,3 +46,50 @@ func (d *Duration) UnmarshalJSON(b []byte) error {
 		return errors.New("invalid duration")
 	}
 }
+
+// Labels метки метрики. Из текста разбираются в формате "host=web-1,env=prod",
+// в JSON задаются объектом или такой же строкой
+type Labels map[string]string
+
+var _ encoding.TextUnmarshaler = (*Labels)(nil)
+var _ json.Unmarshaler = (*Labels)(nil)
+
+func (l *Labels) UnmarshalJSON(b []byte) error {
+	var text string
+	if err := json.Unmarshal(b, &text); err == nil {
+		return l.UnmarshalText([]byte(text))
+	}
+
+	var labels map[string]string
+	if err := json.Unmarshal(b, &labels); err != nil {
+		return errors.New("invalid labels")
+	}
+	for name := range labels {
+		if name == "" {
+			return errors.New("invalid labels: empty label name")
+		}
+	}
+	*l = labels
+
+	return nil
+}
+
+func (l *Labels) UnmarshalText(text []byte) error {
+	labels := make(Labels)
+	for _, pair := range strings.Split(string(text), ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		name, value, found := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		if !found || name == "" {
+			return fmt.Errorf("invalid label %q, expected name=value", pair)
+		}
+		labels[name] = strings.TrimSpace(value)
+	}
+	*l = labels
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLabels_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		want    Labels
+		wantErr bool
+	}{
+		{
+			name: "Object",
+			json: `{"host": "web-1", "env": "prod"}`,
+			want: Labels{"host": "web-1", "env": "prod"},
+		},
+		{
+			name: "String",
+			json: `"host=web-1, env=prod"`,
+			want: Labels{"host": "web-1", "env": "prod"},
+		},
+		{name: "Empty object", json: `{}`, want: Labels{}},
+		{name: "Invalid string", json: `"host"`, wantErr: true},
+		{name: "Empty label name", json: `{"": "web-1"}`, wantErr: true},
+		{name: "Non-string value", json: `{"host": 1}`, wantErr: true},
+		{name: "Array", json: `["host=web-1"]`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var labels Labels
+			err := json.Unmarshal([]byte(tt.json), &labels)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, labels)
+		})
+	}
+}
@@ -0,0 +1,224 @@
+// Package ddsketch реализует DDSketch - квантильный скетч с гарантированной относительной точностью,
+// который можно объединять с другими скетчами той же точности.
+//
+// Значение v попадает в корзину с индексом ceil(log_gamma(v)), где gamma = (1 + a) / (1 - a),
+// поэтому любой квантиль оценивается с относительной ошибкой не больше a.
+package ddsketch
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// DefaultRelativeAccuracy относительная точность по умолчанию - 1%
+const DefaultRelativeAccuracy = 0.01
+
+var ErrAccuracyMismatch = errors.New("sketches have different relative accuracy")
+
+// Sketch квантильный скетч. Отрицательные и положительные значения хранятся в отдельных корзинах
+type Sketch struct {
+	RelativeAccuracy float64        `json:"relative_accuracy"`  // Относительная точность оценки квантилей
+	Positive         map[int]uint64 `json:"positive,omitempty"` // Корзины положительных значений
+	Negative         map[int]uint64 `json:"negative,omitempty"` // Корзины модулей отрицательных значений
+	Zero             uint64         `json:"zero,omitempty"`     // Количество значений, неотличимых от нуля
+	Count            uint64         `json:"count"`              // Общее количество значений
+	Sum              float64        `json:"sum"`                // Сумма всех значений
+	Min              float64        `json:"min"`                // Минимальное значение
+	Max              float64        `json:"max"`                // Максимальное значение
+}
+
+// New создаёт пустой скетч с относительной точностью из интервала (0, 1)
+func New(relativeAccuracy float64) (*Sketch, error) {
+	s := &Sketch{RelativeAccuracy: relativeAccuracy}
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Add добавляет значение в скетч
+func (s *Sketch) Add(value float64) {
+	s.AddN(value, 1)
+}
+
+// AddN добавляет значение в скетч count раз
+func (s *Sketch) AddN(value float64, count uint64) {
+	if count == 0 {
+		return
+	}
+
+	switch {
+	case value > s.minIndexable():
+		if s.Positive == nil {
+			s.Positive = make(map[int]uint64)
+		}
+		s.Positive[s.index(value)] += count
+	case value < -s.minIndexable():
+		if s.Negative == nil {
+			s.Negative = make(map[int]uint64)
+		}
+		s.Negative[s.index(-value)] += count
+	default:
+		s.Zero += count
+	}
+
+	if s.Count == 0 || value < s.Min {
+		s.Min = value
+	}
+	if s.Count == 0 || value > s.Max {
+		s.Max = value
+	}
+	s.Count += count
+	s.Sum += value * float64(count)
+}
+
+// Merge добавляет значения другого скетча с такой же точностью
+func (s *Sketch) Merge(other *Sketch) error {
+	if s.RelativeAccuracy != other.RelativeAccuracy {
+		return ErrAccuracyMismatch
+	}
+	if other.Count == 0 {
+		return nil
+	}
+
+	if len(other.Positive) > 0 && s.Positive == nil {
+		s.Positive = make(map[int]uint64, len(other.Positive))
+	}
+	for i, count := range other.Positive {
+		s.Positive[i] += count
+	}
+	if len(other.Negative) > 0 && s.Negative == nil {
+		s.Negative = make(map[int]uint64, len(other.Negative))
+	}
+	for i, count := range other.Negative {
+		s.Negative[i] += count
+	}
+	s.Zero += other.Zero
+
+	if s.Count == 0 || other.Min < s.Min {
+		s.Min = other.Min
+	}
+	if s.Count == 0 || other.Max > s.Max {
+		s.Max = other.Max
+	}
+	s.Count += other.Count
+	s.Sum += other.Sum
+
+	return nil
+}
+
+// Quantile оценивает квантиль q из отрезка [0, 1]. Для пустого скетча или неверного q возвращает NaN
+func (s *Sketch) Quantile(q float64) float64 {
+	if s.Count == 0 || q < 0 || q > 1 || math.IsNaN(q) {
+		return math.NaN()
+	}
+	if q == 0 {
+		return s.Min
+	}
+	if q == 1 {
+		return s.Max
+	}
+
+	rank := uint64(q * float64(s.Count-1))
+	var seen uint64
+
+	negative := sortedIndexes(s.Negative)
+	for i := len(negative) - 1; i >= 0; i-- {
+		seen += s.Negative[negative[i]]
+		if seen > rank {
+			return s.clamp(-s.value(negative[i]))
+		}
+	}
+
+	seen += s.Zero
+	if seen > rank {
+		return 0
+	}
+
+	for _, index := range sortedIndexes(s.Positive) {
+		seen += s.Positive[index]
+		if seen > rank {
+			return s.clamp(s.value(index))
+		}
+	}
+
+	return s.Max
+}
+
+// Clone возвращает независимую копию скетча
+func (s *Sketch) Clone() *Sketch {
+	clone := *s
+	clone.Positive = copyBins(s.Positive)
+	clone.Negative = copyBins(s.Negative)
+
+	return &clone
+}
+
+// Validate проверяет точность скетча и согласованность его корзин
+func (s *Sketch) Validate() error {
+	if !(s.RelativeAccuracy > 0 && s.RelativeAccuracy < 1) {
+		return fmt.Errorf("relative accuracy must be in (0, 1), got %v", s.RelativeAccuracy)
+	}
+
+	total := s.Zero
+	for _, count := range s.Positive {
+		total += count
+	}
+	for _, count := range s.Negative {
+		total += count
+	}
+	if total != s.Count {
+		return errors.New("sketch count does not match its bins")
+	}
+
+	return nil
+}
+
+func (s *Sketch) gamma() float64 {
+	return (1 + s.RelativeAccuracy) / (1 - s.RelativeAccuracy)
+}
+
+// minIndexable минимальное по модулю значение, для которого индекс корзины не выходит за пределы int32
+func (s *Sketch) minIndexable() float64 {
+	return math.Max(math.Pow(s.gamma(), math.MinInt32+1), math.SmallestNonzeroFloat64*s.gamma())
+}
+
+func (s *Sketch) index(value float64) int {
+	return int(math.Ceil(math.Log(value) / math.Log(s.gamma())))
+}
+
+// value возвращает оценку значений корзины, относительная ошибка которой не превышает точность скетча
+func (s *Sketch) value(index int) float64 {
+	gamma := s.gamma()
+	return 2 * math.Pow(gamma, float64(index)) / (gamma + 1)
+}
+
+func (s *Sketch) clamp(value float64) float64 {
+	return math.Max(s.Min, math.Min(s.Max, value))
+}
+
+func sortedIndexes(bins map[int]uint64) []int {
+	indexes := make([]int, 0, len(bins))
+	for index := range bins {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	return indexes
+}
+
+func copyBins(bins map[int]uint64) map[int]uint64 {
+	if bins == nil {
+		return nil
+	}
+
+	clone := make(map[int]uint64, len(bins))
+	for index, count := range bins {
+		clone[index] = count
+	}
+
+	return clone
+}
@@ -0,0 +1,72 @@
+package ddsketch
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSketch_QuantileAccuracy(t *testing.T) {
+	accuracy := 0.01
+	sketch, err := New(accuracy)
+	require.NoError(t, err)
+
+	rnd := rand.New(rand.NewSource(42))
+	values := make([]float64, 10000)
+	for i := range values {
+		values[i] = math.Exp(rnd.NormFloat64()*2) - 0.5
+		sketch.Add(values[i])
+	}
+	sort.Float64s(values)
+
+	for _, q := range []float64{0.01, 0.25, 0.5, 0.9, 0.99} {
+		want := values[int(q*float64(len(values)-1))]
+		got := sketch.Quantile(q)
+		assert.InDeltaf(t, want, got, math.Abs(want)*accuracy+1e-9, "quantile %v", q)
+	}
+	assert.Equal(t, values[0], sketch.Quantile(0))
+	assert.Equal(t, values[len(values)-1], sketch.Quantile(1))
+	assert.NoError(t, sketch.Validate())
+}
+
+func TestSketch_Merge(t *testing.T) {
+	first, _ := New(0.02)
+	second, _ := New(0.02)
+	first.Add(-3)
+	first.Add(0)
+	second.Add(7)
+
+	require.NoError(t, first.Merge(second))
+	assert.Equal(t, uint64(3), first.Count)
+	assert.Equal(t, float64(4), first.Sum)
+	assert.Equal(t, float64(-3), first.Min)
+	assert.Equal(t, float64(7), first.Max)
+	assert.Equal(t, float64(0), first.Quantile(0.5))
+
+	other, _ := New(0.05)
+	assert.ErrorIs(t, first.Merge(other), ErrAccuracyMismatch)
+}
+
+func TestSketch_AddN(t *testing.T) {
+	weighted, _ := New(DefaultRelativeAccuracy)
+	weighted.AddN(10, 1000)
+	weighted.AddN(-1, 0)
+	repeated, _ := New(DefaultRelativeAccuracy)
+	for i := 0; i < 1000; i++ {
+		repeated.Add(10)
+	}
+
+	assert.Equal(t, repeated, weighted)
+}
+
+func TestSketch_Empty(t *testing.T) {
+	sketch, _ := New(DefaultRelativeAccuracy)
+	assert.True(t, math.IsNaN(sketch.Quantile(0.5)))
+
+	_, err := New(1)
+	assert.Error(t, err)
+}
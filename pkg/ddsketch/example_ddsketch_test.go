@@ -0,0 +1,25 @@
+package ddsketch
+
+import "fmt"
+
+func Example() {
+	first, _ := New(DefaultRelativeAccuracy)
+	second, _ := New(DefaultRelativeAccuracy)
+	for i := 1; i <= 50; i++ {
+		first.Add(float64(i))
+		second.Add(float64(i + 50))
+	}
+
+	if err := first.Merge(second); err != nil {
+		panic(err)
+	}
+
+	fmt.Println(first.Count)
+	fmt.Printf("%.0f\n", first.Quantile(0.5))
+	fmt.Printf("%.0f\n", first.Quantile(0.99))
+
+	// Output:
+	// 100
+	// 50
+	// 99
+}